@@ -24,11 +24,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/FedorLap2006/disgolf"
 	"github.com/bwmarrin/discordgo"
 	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/cache"
 	"github.com/jaredallard/miku/internal/handler"
+	"github.com/jaredallard/miku/internal/settings"
 	"github.com/jaredallard/miku/internal/version"
 	"golang.org/x/term"
 )
@@ -38,6 +41,11 @@ func main() {
 	token := os.Getenv("MIKU_DISCORD_TOKEN")
 	channelID := os.Getenv("MIKU_DISCORD_CHANNEL_ID")
 	logFormat := os.Getenv("MIKU_LOG_FORMAT")
+	providerTimeout := os.Getenv("MIKU_PROVIDER_TIMEOUT")
+	cachePath := os.Getenv("MIKU_CACHE_PATH")
+	cacheTTL := os.Getenv("MIKU_CACHE_TTL")
+	configPath := os.Getenv("MIKU_CONFIG_PATH")
+	settingsPath := os.Getenv("MIKU_SETTINGS_PATH")
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt, syscall.SIGSEGV)
 	defer cancel()
@@ -62,14 +70,74 @@ func main() {
 	if err != nil {
 		log.With("err", err).Fatal("failed to create bot")
 	}
-	bot.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsGuildMessages)
+	// Guilds is required to know our permissions in each channel, and to
+	// receive thread events so that we know which channel a thread
+	// belongs to. Messages in threads are covered by GuildMessages.
+	bot.Identify.Intents = discordgo.MakeIntent(discordgo.IntentsGuilds | discordgo.IntentsGuildMessages |
+		discordgo.IntentsDirectMessages)
 
-	h := handler.New(&handler.Config{
+	conf := &handler.Config{
 		ChannelID: channelID,
-	}, logger)
+	}
+	if configPath != "" {
+		guilds, err := handler.LoadGuildConfigs(configPath)
+		if err != nil {
+			logger.With("err", err).Fatal("failed to load MIKU_CONFIG_PATH")
+		}
+		conf.Guilds = guilds
+	}
+	if providerTimeout != "" {
+		d, err := time.ParseDuration(providerTimeout)
+		if err != nil {
+			logger.With("err", err).Fatal("failed to parse MIKU_PROVIDER_TIMEOUT")
+		}
+		conf.ProviderTimeout = d
+	}
+
+	if cachePath != "" {
+		var opts cache.Options
+		if cacheTTL != "" {
+			d, err := time.ParseDuration(cacheTTL)
+			if err != nil {
+				logger.With("err", err).Fatal("failed to parse MIKU_CACHE_TTL")
+			}
+			opts.TTL = d
+		}
+
+		c, err := cache.Open(cachePath, opts)
+		if err != nil {
+			logger.With("err", err).Fatal("failed to open cache")
+		}
+		defer c.Close() //nolint:errcheck,gosec // Why: Best effort.
+
+		logger.With("cache.path", cachePath).Info("enabled cache")
+		conf.Cache = c
+	}
+
+	if settingsPath != "" {
+		st, err := settings.Open(settingsPath)
+		if err != nil {
+			logger.With("err", err).Fatal("failed to open settings")
+		}
+		defer st.Close() //nolint:errcheck,gosec // Why: Best effort.
+
+		logger.With("settings.path", settingsPath).Info("enabled persistent settings")
+		conf.Settings = st
+	}
+
+	h := handler.New(conf, logger)
 
 	// Setup the main handler.
 	bot.AddHandler(h.EventHandler)
+	bot.AddHandler(h.MessageUpdateHandler)
+	bot.AddHandler(h.MessageDeleteHandler)
+	bot.AddHandler(h.GuildCreateHandler)
+
+	// Setup application (slash) commands.
+	for _, cmd := range h.Commands() {
+		bot.Router.Register(cmd)
+	}
+	bot.AddHandler(bot.Router.HandleInteraction)
 
 	logger.Info("starting bot")
 	if err := bot.Open(); err != nil {
@@ -77,6 +145,10 @@ func main() {
 	}
 	defer bot.Close() //nolint:errcheck,gosec // Why: Best effort.
 
+	if err := bot.Router.Sync(bot.Session, "", ""); err != nil {
+		logger.With("err", err).Error("failed to register application commands")
+	}
+
 	if err := bot.UpdateCustomStatus(fmt.Sprintf("Watching for music links (%s)", version.Version)); err != nil {
 		logger.With("err", err).Warn("failed to update listening status")
 	}
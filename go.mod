@@ -8,6 +8,7 @@ require (
 	github.com/charmbracelet/log v0.4.2
 	github.com/minchao/go-apple-music v0.0.0-20230815040201-3b2aec2d7ffe
 	github.com/zmb3/spotify/v2 v2.4.3
+	go.etcd.io/bbolt v1.5.0
 	golang.org/x/oauth2 v0.36.0
 	golang.org/x/term v0.45.0
 	mvdan.cc/xurls/v2 v2.6.0
@@ -0,0 +1,194 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+// Package cache implements a persistent, on-disk cache with support for
+// expiring entries and caching the absence of a value ("not found").
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// DefaultTTL is the default amount of time a value is cached for.
+	DefaultTTL = 7 * 24 * time.Hour
+
+	// DefaultNegativeTTL is the default amount of time the absence of a
+	// value is cached for. This is shorter than DefaultTTL as things are
+	// added to catalogs far more often than they are removed.
+	DefaultNegativeTTL = time.Hour
+)
+
+var (
+	// ErrMiss is returned when a key is not in the cache, or has expired.
+	ErrMiss = errors.New("cache miss")
+
+	// ErrNotFound is returned when a key was cached as not existing. See
+	// [Cache.SetNotFound].
+	ErrNotFound = errors.New("cached as not found")
+)
+
+// entry is a value stored in the cache.
+type entry struct {
+	// ExpiresAt is when this entry should no longer be used.
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// NotFound denotes that the value didn't exist when it was cached.
+	NotFound bool `json:"not_found,omitempty"`
+
+	// Value is the JSON encoded value.
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Options contains the options for a Cache.
+type Options struct {
+	// TTL is the amount of time a value is cached for. Defaults to
+	// DefaultTTL.
+	TTL time.Duration
+
+	// NegativeTTL is the amount of time the absence of a value is cached
+	// for. Defaults to DefaultNegativeTTL.
+	NegativeTTL time.Duration
+}
+
+// Cache is a persistent cache backed by an embedded database. Values are
+// grouped into buckets and encoded as JSON. It is safe for concurrent
+// use.
+type Cache struct {
+	db   *bolt.DB
+	opts Options
+}
+
+// Open opens (creating if needed) the cache at the provided path.
+func Open(path string, opts Options) (*Cache, error) {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultTTL
+	}
+	if opts.NegativeTTL <= 0 {
+		opts.NegativeTTL = DefaultNegativeTTL
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
+	}
+
+	return &Cache{db, opts}, nil
+}
+
+// Close closes the underlying database.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+// Get decodes the value stored under key in bucket into v. ErrMiss is
+// returned if the key isn't cached (or has expired) and ErrNotFound is
+// returned if the key was cached as not existing.
+func (c *Cache) Get(bucket, key string, v any) error {
+	var e entry
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrMiss
+		}
+
+		raw := b.Get([]byte(key))
+		if raw == nil {
+			return ErrMiss
+		}
+
+		return json.Unmarshal(raw, &e)
+	})
+	if err != nil {
+		return err
+	}
+
+	if time.Now().After(e.ExpiresAt) {
+		return ErrMiss
+	}
+	if e.NotFound {
+		return ErrNotFound
+	}
+
+	return json.Unmarshal(e.Value, v)
+}
+
+// Set stores v under key in bucket.
+func (c *Cache) Set(bucket, key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	return c.put(bucket, key, &entry{ExpiresAt: time.Now().Add(c.opts.TTL), Value: b})
+}
+
+// SetNotFound stores that key in bucket doesn't exist. Subsequent calls
+// to Get will return ErrNotFound until the entry expires.
+func (c *Cache) SetNotFound(bucket, key string) error {
+	return c.put(bucket, key, &entry{ExpiresAt: time.Now().Add(c.opts.NegativeTTL), NotFound: true})
+}
+
+// put writes an entry to the database.
+func (c *Cache) put(bucket, key string, e *entry) error {
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode entry: %w", err)
+	}
+
+	return c.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		return b.Put([]byte(key), raw)
+	})
+}
+
+// trackingParams are query parameters that only exist to track who
+// shared a link and do not change what the link points to.
+var trackingParams = []string{"si", "fbclid", "igshid", "feature", "context"}
+
+// NormalizeURL returns a normalized form of the provided URL suitable
+// for use as a cache key. Tracking parameters, fragments and trailing
+// slashes are removed, the hostname is lowercased and the remaining
+// query parameters are sorted.
+func NormalizeURL(u *url.URL) string {
+	q := u.Query()
+	for k := range q {
+		if strings.HasPrefix(k, "utm_") || slices.Contains(trackingParams, k) {
+			q.Del(k)
+		}
+	}
+
+	n := url.URL{
+		Scheme:   "https",
+		Host:     strings.ToLower(u.Host),
+		Path:     strings.TrimSuffix(u.Path, "/"),
+		RawQuery: q.Encode(),
+	}
+	return n.String()
+}
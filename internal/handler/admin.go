@@ -0,0 +1,323 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/FedorLap2006/disgolf"
+	"github.com/bwmarrin/discordgo"
+)
+
+// adminCommand returns the /miku command, which is used by server admins
+// to configure miku.
+func (h *Handler) adminCommand() *disgolf.Command {
+	modeChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(ChannelModes))
+	for _, mode := range ChannelModes {
+		modeChoices = append(modeChoices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  string(mode),
+			Value: string(mode),
+		})
+	}
+
+	channelOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionChannel,
+		Name:        "channel",
+		Description: "Channel to change, defaults to the current channel",
+		ChannelTypes: []discordgo.ChannelType{
+			discordgo.ChannelTypeGuildText,
+			discordgo.ChannelTypeGuildNews,
+			discordgo.ChannelTypeGuildForum,
+		},
+	}
+
+	settingChoices := make([]*discordgo.ApplicationCommandOptionChoice, 0)
+	for _, setting := range h.guildSettings() {
+		settingChoices = append(settingChoices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  setting.name,
+			Value: setting.name,
+		})
+	}
+
+	return &disgolf.Command{
+		Name:        "miku",
+		Description: "Configure miku for this server",
+		Type:        discordgo.ChatApplicationCommand,
+		Middlewares: []disgolf.Handler{disgolf.HandlerFunc(h.requireManageGuild)},
+		SubCommands: disgolf.NewRouter([]*disgolf.Command{{
+			Name:        "channels",
+			Description: "Configure which channels miku watches for music links",
+			SubCommands: disgolf.NewRouter([]*disgolf.Command{{
+				Name:        "watch",
+				Description: "Watch a channel for music links",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "delete: convert and delete, keep: convert and reply, mention: reply only when mentioned",
+					Required:    true,
+					Choices:     modeChoices,
+				}, channelOption},
+				Handler: disgolf.HandlerFunc(h.watchChannelCommand),
+			}, {
+				Name:        "unwatch",
+				Description: "Stop watching a channel for music links",
+				Options:     []*discordgo.ApplicationCommandOption{channelOption},
+				Handler:     disgolf.HandlerFunc(h.unwatchChannelCommand),
+			}, {
+				Name:        "list",
+				Description: "List the channels being watched for music links",
+				Handler:     disgolf.HandlerFunc(h.listChannelsCommand),
+			}}),
+		}, {
+			Name:        "config",
+			Description: "View or change miku's settings for this server",
+			SubCommands: disgolf.NewRouter([]*disgolf.Command{{
+				Name:        "get",
+				Description: "Show the current value of every setting, or a single one",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "setting",
+					Description: "Setting to show",
+					Choices:     settingChoices,
+				}},
+				Handler: disgolf.HandlerFunc(h.configGetCommand),
+			}, {
+				Name:        "set",
+				Description: "Change a setting",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "setting",
+					Description: "Setting to change",
+					Required:    true,
+					Choices:     settingChoices,
+				}, {
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "value",
+					Description: "New value, see /miku config get for the format",
+					Required:    true,
+				}},
+				Handler: disgolf.HandlerFunc(h.configSetCommand),
+			}, {
+				Name:        "reset",
+				Description: "Reset every setting, or a single one, to its default",
+				Options: []*discordgo.ApplicationCommandOption{{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "setting",
+					Description: "Setting to reset",
+					Choices:     settingChoices,
+				}},
+				Handler: disgolf.HandlerFunc(h.configResetCommand),
+			}}),
+		}}),
+	}
+}
+
+// errNotWatched is returned when trying to stop watching a channel that
+// isn't being watched.
+var errNotWatched = errors.New("channel is not being watched")
+
+// respondEphemeral responds to the interaction with a message only the
+// user that created it can see.
+func (h *Handler) respondEphemeral(dctx *disgolf.Ctx, content string) {
+	if err := dctx.Respond(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		h.log.With("err", err).Error("failed to respond to interaction")
+	}
+}
+
+// requireManageGuild is a middleware that only allows members with the
+// Manage Server permission to run a command.
+func (h *Handler) requireManageGuild(dctx *disgolf.Ctx) {
+	m := dctx.Interaction.Member
+	if dctx.Interaction.GuildID == "" || m == nil {
+		h.respondEphemeral(dctx, "This command can only be used in a server.")
+		return
+	}
+
+	if m.Permissions&discordgo.PermissionManageGuild == 0 {
+		h.log.With("user.id", interactionUser(dctx.Interaction).ID).Warn("denied admin command")
+		h.respondEphemeral(dctx, "You need the Manage Server permission to use this command.")
+		return
+	}
+
+	dctx.Next()
+}
+
+// optionChannelID returns the ID of the channel passed to the "channel"
+// option, falling back to the channel the interaction happened in.
+func optionChannelID(dctx *disgolf.Ctx) string {
+	if opt, ok := dctx.Options["channel"]; ok {
+		return opt.ChannelValue(nil).ID
+	}
+	return dctx.Interaction.ChannelID
+}
+
+// watchChannelCommand implements the /miku channels watch command.
+func (h *Handler) watchChannelCommand(dctx *disgolf.Ctx) {
+	channelID := optionChannelID(dctx)
+	mode := ChannelMode(dctx.Options["mode"].StringValue())
+	if !mode.Valid() {
+		h.respondEphemeral(dctx, fmt.Sprintf("Unknown mode %q.", mode))
+		return
+	}
+
+	if err := h.guilds.update(dctx.Interaction.GuildID, func(gc *GuildConfig) error {
+		if gc.Channels == nil {
+			gc.Channels = make(map[string]ChannelMode)
+		}
+		gc.Channels[channelID] = mode
+		return nil
+	}); err != nil {
+		h.log.With("err", err).Error("failed to update guild settings")
+		h.respondEphemeral(dctx, fmt.Sprintf("Failed to save settings: %v", err))
+		return
+	}
+
+	h.log.With("guild.id", dctx.Interaction.GuildID, "channel.id", channelID, "channel.mode", mode).
+		Info("watching channel")
+	h.respondEphemeral(dctx, fmt.Sprintf("Watching <#%s> (mode: %s).", channelID, mode))
+}
+
+// unwatchChannelCommand implements the /miku channels unwatch command.
+func (h *Handler) unwatchChannelCommand(dctx *disgolf.Ctx) {
+	channelID := optionChannelID(dctx)
+	if err := h.guilds.update(dctx.Interaction.GuildID, func(gc *GuildConfig) error {
+		if _, ok := gc.Channels[channelID]; !ok {
+			return errNotWatched
+		}
+		delete(gc.Channels, channelID)
+		return nil
+	}); err != nil {
+		if errors.Is(err, errNotWatched) {
+			h.respondEphemeral(dctx, fmt.Sprintf("<#%s> isn't being watched.", channelID))
+			return
+		}
+
+		h.log.With("err", err).Error("failed to update guild settings")
+		h.respondEphemeral(dctx, fmt.Sprintf("Failed to save settings: %v", err))
+		return
+	}
+
+	h.log.With("guild.id", dctx.Interaction.GuildID, "channel.id", channelID).Info("stopped watching channel")
+	h.respondEphemeral(dctx, fmt.Sprintf("Stopped watching <#%s>.", channelID))
+}
+
+// listChannelsCommand implements the /miku channels list command.
+func (h *Handler) listChannelsCommand(dctx *disgolf.Ctx) {
+	gc := h.guilds.get(dctx.Interaction.GuildID)
+	if len(gc.Channels) == 0 {
+		h.respondEphemeral(dctx, "No channels are being watched.")
+		return
+	}
+
+	h.respondEphemeral(dctx, "Watched channels: "+formatChannels(gc.Channels))
+}
+
+// configGetCommand implements the /miku config get command.
+func (h *Handler) configGetCommand(dctx *disgolf.Ctx) {
+	gc := h.guilds.get(dctx.Interaction.GuildID)
+
+	settings := h.guildSettings()
+	if opt, ok := dctx.Options["setting"]; ok {
+		setting, ok := h.guildSetting(opt.StringValue())
+		if !ok {
+			h.respondEphemeral(dctx, fmt.Sprintf("Unknown setting %q.", opt.StringValue()))
+			return
+		}
+		settings = []guildSetting{setting}
+	}
+
+	var sb strings.Builder
+	for _, setting := range settings {
+		fmt.Fprintf(&sb, "**%s**: %s\n-# %s\n", setting.name, setting.get(gc), setting.description)
+	}
+	h.respondEphemeral(dctx, sb.String())
+}
+
+// configSetCommand implements the /miku config set command.
+func (h *Handler) configSetCommand(dctx *disgolf.Ctx) {
+	name := dctx.Options["setting"].StringValue()
+	value := strings.TrimSpace(dctx.Options["value"].StringValue())
+
+	setting, ok := h.guildSetting(name)
+	if !ok {
+		h.respondEphemeral(dctx, fmt.Sprintf("Unknown setting %q.", name))
+		return
+	}
+
+	var invalid error
+	if err := h.guilds.update(dctx.Interaction.GuildID, func(gc *GuildConfig) error {
+		invalid = setting.set(gc, value)
+		return invalid
+	}); err != nil {
+		if invalid != nil {
+			h.respondEphemeral(dctx, fmt.Sprintf("Invalid value for %s: %v", name, invalid))
+			return
+		}
+
+		h.log.With("err", err).Error("failed to update guild settings")
+		h.respondEphemeral(dctx, fmt.Sprintf("Failed to save settings: %v", err))
+		return
+	}
+
+	h.log.With("guild.id", dctx.Interaction.GuildID, "setting", name, "value", value).Info("changed guild setting")
+	h.respondEphemeral(dctx, fmt.Sprintf("**%s** is now %s.", name, setting.get(h.guilds.get(dctx.Interaction.GuildID))))
+}
+
+// configResetCommand implements the /miku config reset command.
+func (h *Handler) configResetCommand(dctx *disgolf.Ctx) {
+	guildID := dctx.Interaction.GuildID
+
+	opt, ok := dctx.Options["setting"]
+	if !ok {
+		if err := h.guilds.reset(guildID); err != nil {
+			h.log.With("err", err).Error("failed to reset guild settings")
+			h.respondEphemeral(dctx, fmt.Sprintf("Failed to reset settings: %v", err))
+			return
+		}
+
+		h.log.With("guild.id", guildID).Info("reset guild settings")
+		h.respondEphemeral(dctx, "Reset every setting to its default.")
+		return
+	}
+
+	setting, ok := h.guildSetting(opt.StringValue())
+	if !ok {
+		h.respondEphemeral(dctx, fmt.Sprintf("Unknown setting %q.", opt.StringValue()))
+		return
+	}
+
+	if err := h.guilds.update(guildID, func(gc *GuildConfig) error {
+		setting.reset(gc, h.guilds.defaultConfig(guildID))
+		return nil
+	}); err != nil {
+		h.log.With("err", err).Error("failed to update guild settings")
+		h.respondEphemeral(dctx, fmt.Sprintf("Failed to save settings: %v", err))
+		return
+	}
+
+	h.log.With("guild.id", guildID, "setting", setting.name).Info("reset guild setting")
+	h.respondEphemeral(dctx, fmt.Sprintf("**%s** is now %s.", setting.name, setting.get(h.guilds.get(guildID))))
+}
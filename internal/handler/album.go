@@ -0,0 +1,76 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+// errAlbumsNotSupported is returned when a provider doesn't implement
+// streamingproviders.AlbumProvider.
+var errAlbumsNotSupported = errors.New("provider does not support albums")
+
+// NewAlbumURL takes a URL and searches all enabled providers for the
+// album it points to. It then searches all providers (minus the one the
+// album was found on) and returns the alternatives.
+//
+//nolint:gocritic // Why: Documented above.
+func (h *Handler) NewAlbumURL(ctx context.Context, urlStr string) (*streamingproviders.Album,
+	*Alternatives[streamingproviders.Album], error) {
+	album := findByURL(ctx, h, "album", urlStr, func(ctx context.Context, sp streamingproviders.Provider,
+		u *url.URL) (*streamingproviders.Album, error) {
+		ap, ok := sp.(streamingproviders.AlbumProvider)
+		if !ok {
+			return nil, errAlbumsNotSupported
+		}
+		return ap.LookupAlbumByURL(ctx, u)
+	})
+	if album == nil {
+		return nil, nil, ErrFailedToFindOriginal
+	}
+	h.log.With(
+		"album.upc", album.UPC,
+		"album.provider", album.Provider.Identifier,
+		"album.title", album.Title,
+		"album.artists", album.Artists,
+	).Info("found original album")
+
+	// Search all of the providers (minus the one we found it on) for the
+	// album and return all of the results.
+	alts := searchAlts(ctx, h, "album", album.Provider.Identifier, func(ctx context.Context,
+		sp streamingproviders.Provider) (*streamingproviders.Album, error) {
+		ap, ok := sp.(streamingproviders.AlbumProvider)
+		if !ok {
+			return nil, errAlbumsNotSupported
+		}
+		return ap.SearchAlbum(ctx, album)
+	})
+	for _, alt := range alts.Found {
+		h.log.With(
+			"album.provider", alt.Provider.Identifier,
+			"album.title", alt.Title,
+			"album.artists", alt.Artists,
+		).Info("found alternative album")
+	}
+
+	return album, alts, nil
+}
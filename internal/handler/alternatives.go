@@ -0,0 +1,129 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+// DefaultProviderTimeout is the default amount of time a provider is
+// given to search for an alternative before it is skipped.
+const DefaultProviderTimeout = 5 * time.Second
+
+// Alternatives contains the results of searching every other enabled
+// provider for a song, album or artist.
+type Alternatives[T any] struct {
+	// Found contains the result from every provider that found it, in
+	// the order the providers are enabled in.
+	Found []*T
+
+	// TimedOut contains the providers that didn't respond within the
+	// configured provider timeout.
+	TimedOut []streamingproviders.Info
+}
+
+// searchAlts calls search for every enabled provider, except the one
+// identified by skipID, concurrently. Each provider is given
+// Config.ProviderTimeout to respond, after which it is recorded as
+// having timed out. kind is used for logging (e.g., "song").
+func searchAlts[T any](ctx context.Context, h *Handler, kind, skipID string,
+	search func(context.Context, streamingproviders.Provider) (*T, error)) *Alternatives[T] {
+	timeout := h.c.ProviderTimeout
+	if timeout <= 0 {
+		timeout = DefaultProviderTimeout
+	}
+
+	type result struct {
+		v   *T
+		err error
+	}
+
+	// Buffered so that providers that respond after we stop waiting on
+	// them don't leak their goroutine.
+	results := make([]chan result, len(h.sps))
+	for i, sp := range h.sps {
+		if sp.Info().Identifier == skipID {
+			continue
+		}
+
+		results[i] = make(chan result, 1)
+		go func() {
+			pctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			h.log.With("provider.id", sp.Info().Identifier).Debugf("searching for alternative %s", kind)
+			v, err := search(pctx, sp)
+			if err == nil && pctx.Err() != nil {
+				err = pctx.Err()
+			}
+			results[i] <- result{v, err}
+		}()
+	}
+
+	// All providers started at (roughly) the same time, so they share a
+	// deadline.
+	wctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	alts := &Alternatives[T]{}
+	for i, sp := range h.sps {
+		if results[i] == nil {
+			continue
+		}
+
+		pinfo := sp.Info()
+		plog := h.log.With("provider.id", pinfo.Identifier)
+
+		var res result
+		select {
+		case res = <-results[i]:
+		case <-wctx.Done():
+			// Don't wait on providers that haven't responded yet, but still
+			// use the result of those that have.
+			select {
+			case res = <-results[i]:
+			default:
+				res = result{err: context.DeadlineExceeded}
+			}
+		}
+
+		if errors.Is(res.err, context.DeadlineExceeded) {
+			plog.Warnf("timed out searching for alternative %s", kind)
+			alts.TimedOut = append(alts.TimedOut, pinfo)
+			continue
+		}
+		if res.err != nil {
+			plog.With("err", res.err).Debugf("failed to search for %s", kind)
+			continue
+		}
+		if res.v == nil {
+			// Providers shouldn't return nothing without an error, but a
+			// nil result would break callers of searchAlts.
+			plog.Debugf("no alternative %s returned", kind)
+			continue
+		}
+
+		alts.Found = append(alts.Found, res.v)
+	}
+
+	return alts
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+// errArtistsNotSupported is returned when a provider doesn't implement
+// streamingproviders.ArtistProvider.
+var errArtistsNotSupported = errors.New("provider does not support artists")
+
+// NewArtistURL takes a URL and searches all enabled providers for the
+// artist it points to. It then searches all providers (minus the one the
+// artist was found on) and returns the alternatives.
+//
+//nolint:gocritic // Why: Documented above.
+func (h *Handler) NewArtistURL(ctx context.Context, urlStr string) (*streamingproviders.Artist,
+	*Alternatives[streamingproviders.Artist], error) {
+	artist := findByURL(ctx, h, "artist", urlStr, func(ctx context.Context, sp streamingproviders.Provider,
+		u *url.URL) (*streamingproviders.Artist, error) {
+		ap, ok := sp.(streamingproviders.ArtistProvider)
+		if !ok {
+			return nil, errArtistsNotSupported
+		}
+		return ap.LookupArtistByURL(ctx, u)
+	})
+	if artist == nil {
+		return nil, nil, ErrFailedToFindOriginal
+	}
+	h.log.With(
+		"artist.provider", artist.Provider.Identifier,
+		"artist.name", artist.Name,
+		"artist.top_track_isrcs", artist.TopTrackISRCs,
+	).Info("found original artist")
+
+	// Search all of the providers (minus the one we found it on) for the
+	// artist and return all of the results.
+	alts := searchAlts(ctx, h, "artist", artist.Provider.Identifier, func(ctx context.Context,
+		sp streamingproviders.Provider) (*streamingproviders.Artist, error) {
+		ap, ok := sp.(streamingproviders.ArtistProvider)
+		if !ok {
+			return nil, errArtistsNotSupported
+		}
+		return ap.SearchArtist(ctx, artist)
+	})
+	for _, alt := range alts.Found {
+		h.log.With(
+			"artist.provider", alt.Provider.Identifier,
+			"artist.name", alt.Name,
+		).Info("found alternative artist")
+	}
+
+	return artist, alts, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jaredallard/miku/internal/cache"
+	"github.com/jaredallard/miku/internal/streamingproviders"
+	"github.com/jaredallard/miku/internal/streamingproviders/applemusic"
+)
+
+const (
+	// songsByURLBucket contains songs keyed by provider and normalized
+	// URL.
+	songsByURLBucket = "songs_by_url"
+
+	// songsByISRCBucket contains songs keyed by provider, ISRC, album
+	// and explicitness.
+	songsByISRCBucket = "songs_by_isrc"
+)
+
+// lookupSongByURL calls LookupSongByURL on the provided provider, using
+// the cache if it is enabled.
+func (h *Handler) lookupSongByURL(ctx context.Context, sp streamingproviders.Provider,
+	u *url.URL) (*streamingproviders.Song, error) {
+	key := sp.Info().Identifier + "|" + cache.NormalizeURL(u)
+	return cached(h, sp, songsByURLBucket, key, func() (*streamingproviders.Song, error) {
+		return sp.LookupSongByURL(ctx, u)
+	})
+}
+
+// searchSong calls Search on the provided provider, using the cache if
+// it is enabled. Songs without an ISRC are never cached as there is
+// nothing reliable to key them by. The album and explicitness of the
+// song are part of the key, as they decide which release is picked when
+// multiple songs share an ISRC (see
+// streamingproviders.BestISRCCandidate).
+func (h *Handler) searchSong(ctx context.Context, sp streamingproviders.Provider,
+	song *streamingproviders.Song) (*streamingproviders.Song, error) {
+	if song.ISRC == "" {
+		return sp.Search(ctx, song)
+	}
+
+	explicit := strconv.FormatBool(song.Explicit)
+	if song.ExplicitUnknown {
+		explicit = "unknown"
+	}
+	key := cacheKeyPrefix(ctx, sp) + song.ISRC + "|" + strings.ToLower(strings.TrimSpace(song.Album)) + "|" + explicit
+	return cached(h, sp, songsByISRCBucket, key, func() (*streamingproviders.Song, error) {
+		return sp.Search(ctx, song)
+	})
+}
+
+// cacheKeyPrefix returns the prefix for cache keys of searches on the
+// provided provider. Searches return different results depending on the
+// Apple Music storefront, so it is included.
+func cacheKeyPrefix(ctx context.Context, sp streamingproviders.Provider) string {
+	id := sp.Info().Identifier
+	if ap, ok := sp.(*applemusic.Provider); ok {
+		id += "@" + ap.Storefront(ctx)
+	}
+	return id + "|"
+}
+
+// cached returns the song stored under key in bucket, calling fetch and
+// storing its result on a cache miss. Errors wrapping
+// streamingproviders.ErrNotFound are cached as well, other errors are
+// assumed to be transient and are not.
+func cached(h *Handler, sp streamingproviders.Provider, bucket, key string,
+	fetch func() (*streamingproviders.Song, error)) (*streamingproviders.Song, error) {
+	if h.c.Cache == nil {
+		return fetch()
+	}
+	clog := h.log.With("cache.bucket", bucket, "cache.key", key)
+
+	var song streamingproviders.Song
+	switch err := h.c.Cache.Get(bucket, key, &song); {
+	case err == nil:
+		clog.Debug("cache hit")
+
+		// Info may have changed since the song was cached (e.g., emoji).
+		song.Provider = sp.Info()
+		return &song, nil
+	case errors.Is(err, cache.ErrNotFound):
+		clog.Debug("cache hit (not found)")
+		return nil, fmt.Errorf("%w (cached)", streamingproviders.ErrNotFound)
+	case !errors.Is(err, cache.ErrMiss):
+		clog.With("err", err).Warn("failed to read from cache")
+	}
+
+	v, err := fetch()
+	switch {
+	case err == nil:
+		if err := h.c.Cache.Set(bucket, key, v); err != nil {
+			clog.With("err", err).Warn("failed to write to cache")
+		}
+	case errors.Is(err, streamingproviders.ErrNotFound):
+		if err := h.c.Cache.SetNotFound(bucket, key); err != nil {
+			clog.With("err", err).Warn("failed to write to cache")
+		}
+	}
+
+	return v, err
+}
@@ -0,0 +1,247 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FedorLap2006/disgolf"
+	"github.com/bwmarrin/discordgo"
+)
+
+// Commands returns the application commands implemented by the handler.
+// These should be registered with a [disgolf.Router] and synced.
+func (h *Handler) Commands() []*disgolf.Command {
+	return []*disgolf.Command{{
+		Name:        "convert",
+		Description: "Convert a music link into links for every streaming service",
+		Type:        discordgo.ChatApplicationCommand,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "url",
+			Description: "Link to a song, album, artist or playlist",
+			Required:    true,
+		}, {
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "ephemeral",
+			Description: "Only show the result to you",
+		}},
+		Handler: disgolf.HandlerFunc(h.convertCommand),
+	}, {
+		Name:        "search",
+		Description: "Search for a song and get links for every streaming service",
+		Type:        discordgo.ChatApplicationCommand,
+		Options: []*discordgo.ApplicationCommandOption{{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "query",
+			Description: "Song title and artist, e.g. \"never gonna give you up rick astley\"",
+			Required:    true,
+		}, {
+			Type:        discordgo.ApplicationCommandOptionBoolean,
+			Name:        "ephemeral",
+			Description: "Only show the result to you",
+		}},
+		Handler: disgolf.HandlerFunc(h.searchCommand),
+	}, {
+		// Context menu commands can't take options, so there's one for
+		// each visibility.
+		Name:    "Convert music link",
+		Type:    discordgo.MessageApplicationCommand,
+		Handler: h.convertMessageCommand(discordgo.MessageFlagsEphemeral),
+	}, {
+		Name:    "Convert music link (public)",
+		Type:    discordgo.MessageApplicationCommand,
+		Handler: h.convertMessageCommand(0),
+	}, h.adminCommand()}
+}
+
+// interactionUser returns the user that created the interaction. This is
+// stored in a different place depending on if the interaction happened
+// in a guild or a DM.
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// ephemeralFlags returns the message flags to respond to the interaction
+// with based on its "ephemeral" option.
+func ephemeralFlags(dctx *disgolf.Ctx) discordgo.MessageFlags {
+	if opt, ok := dctx.Options["ephemeral"]; ok && opt.BoolValue() {
+		return discordgo.MessageFlagsEphemeral
+	}
+	return 0
+}
+
+// deferResponse acknowledges the interaction so that it can be responded
+// to later. Looking everything up can take longer than the 3 seconds
+// Discord gives us to respond.
+func (h *Handler) deferResponse(dctx *disgolf.Ctx, flags discordgo.MessageFlags) error {
+	return dctx.Respond(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: flags},
+	})
+}
+
+// respondWithError replaces the deferred response with the provided
+// message.
+func (h *Handler) respondWithError(dctx *disgolf.Ctx, content string) {
+	if _, err := dctx.InteractionResponseEdit(dctx.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	}); err != nil {
+		h.log.With("err", err).Error("failed to notify user of failure reason")
+	}
+}
+
+// respondWithConversions replaces the deferred response with the
+// provided conversions. If they don't fit in a single message, the rest
+// are sent as follow-up messages using the provided flags.
+func (h *Handler) respondWithConversions(dctx *disgolf.Ctx, cs []*conversion, flags discordgo.MessageFlags) {
+	msgs := buildMessages(cs)
+	if _, err := dctx.InteractionResponseEdit(dctx.Interaction, &discordgo.WebhookEdit{
+		Embeds:     &msgs[0].Embeds,
+		Components: &msgs[0].Components,
+		Files:      msgs[0].Files,
+	}); err != nil {
+		h.log.With("err", err).Error("failed to send interaction response")
+		return
+	}
+
+	for _, msg := range msgs[1:] {
+		if _, err := dctx.FollowupMessageCreate(dctx.Interaction, true, &discordgo.WebhookParams{
+			Embeds:     msg.Embeds,
+			Components: msg.Components,
+			Files:      msg.Files,
+			Flags:      flags,
+		}); err != nil {
+			h.log.With("err", err).Error("failed to send interaction follow-up")
+			return
+		}
+	}
+}
+
+// convertCommand implements the /convert command.
+func (h *Handler) convertCommand(dctx *disgolf.Ctx) {
+	ctx := context.Background()
+	urlStr := dctx.Options["url"].StringValue()
+
+	flags := ephemeralFlags(dctx)
+
+	h.log.With("url", urlStr).Debug("handling convert command")
+	if err := h.deferResponse(dctx, flags); err != nil {
+		h.log.With("err", err).Error("failed to defer interaction response")
+		return
+	}
+
+	gc := h.guilds.get(dctx.Interaction.GuildID)
+	c, err := h.convertURL(ctx, gc, urlStr, interactionUser(dctx.Interaction))
+	if err != nil {
+		h.log.With("err", err).Error("failed to handle url")
+		if errors.Is(err, ErrFailedToFindOriginal) {
+			h.respondWithError(dctx, "Couldn't find that on any streaming service.")
+			return
+		}
+		h.respondWithError(dctx, fmt.Sprintf("```go\nFailed to process %q: %v\n```", urlStr, err))
+		return
+	}
+
+	h.respondWithConversions(dctx, []*conversion{c}, flags)
+}
+
+// searchCommand implements the /search command.
+func (h *Handler) searchCommand(dctx *disgolf.Ctx) {
+	ctx := context.Background()
+	query := dctx.Options["query"].StringValue()
+
+	flags := ephemeralFlags(dctx)
+
+	h.log.With("query", query).Debug("handling search command")
+	if err := h.deferResponse(dctx, flags); err != nil {
+		h.log.With("err", err).Error("failed to defer interaction response")
+		return
+	}
+
+	gc := h.guilds.get(dctx.Interaction.GuildID)
+	song, alts, err := h.NewSearch(withGuildConfig(ctx, gc), query)
+	if err != nil {
+		h.log.With("err", err).Error("failed to handle search")
+		switch {
+		case errors.Is(err, ErrFailedToFindOriginal):
+			h.respondWithError(dctx, fmt.Sprintf("No results found for %q.", query))
+		case errors.Is(err, ErrNoConfidentMatch):
+			h.respondWithError(dctx, fmt.Sprintf("Couldn't find a song closely matching %q, try adding the artist's name.", query))
+		default:
+			h.respondWithError(dctx, fmt.Sprintf("```go\nFailed to search for %q: %v\n```", query, err))
+		}
+		return
+	}
+
+	c := newSongConversion(song, alts, interactionUser(dctx.Interaction))
+	c.applyGuildConfig(gc)
+	h.respondWithConversions(dctx, []*conversion{c}, flags)
+}
+
+// convertMessageCommand returns a handler for the "Convert music link"
+// context menu commands, which convert every URL in the targeted
+// message. Unlike EventHandler, the targeted message is never deleted.
+func (h *Handler) convertMessageCommand(flags discordgo.MessageFlags) disgolf.HandlerFunc {
+	return func(dctx *disgolf.Ctx) {
+		ctx := context.Background()
+
+		data := dctx.Interaction.ApplicationCommandData()
+		var content string
+		if data.Resolved != nil {
+			if m, ok := data.Resolved.Messages[data.TargetID]; ok {
+				content = m.Content
+			}
+		}
+
+		h.log.With("message.contents", content).Debug("handling convert message command")
+		if err := h.deferResponse(dctx, flags); err != nil {
+			h.log.With("err", err).Error("failed to defer interaction response")
+			return
+		}
+
+		urls := extractURLs(content)
+		if len(urls) == 0 {
+			h.respondWithError(dctx, "That message doesn't contain any links.")
+			return
+		}
+
+		gc := h.guilds.get(dctx.Interaction.GuildID)
+
+		var cs []*conversion
+		for _, u := range urls {
+			c, err := h.convertURL(ctx, gc, u, interactionUser(dctx.Interaction))
+			if err != nil {
+				h.log.With("err", err, "url", u).Error("failed to handle url")
+				continue
+			}
+			cs = append(cs, c)
+		}
+		if len(cs) == 0 {
+			h.respondWithError(dctx, "Couldn't find any of the links in that message on any streaming service.")
+			return
+		}
+
+		h.respondWithConversions(dctx, cs, flags)
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+// conversion is a URL that has been converted into an embed describing
+// what it pointed to, along with buttons linking to every provider it
+// was found on.
+type conversion struct {
+	// embed describes the song, album, artist or playlist that was found.
+	embed *discordgo.MessageEmbed
+
+	// buttons contains a link for every provider the song, album or
+	// artist was found on.
+	buttons []providerLink
+
+	// files are attached to the message, if set.
+	files []attachment
+}
+
+// attachment is a file attached to a conversion. Its contents are kept
+// so that it can be sent more than once (e.g., when a message has to be
+// resent), as the reader of a discordgo.File can only be read once.
+type attachment struct {
+	name        string
+	contentType string
+	data        []byte
+}
+
+// file returns a discordgo.File for the attachment, with a fresh reader.
+func (a *attachment) file() *discordgo.File {
+	return &discordgo.File{
+		Name:        a.name,
+		ContentType: a.contentType,
+		Reader:      bytes.NewReader(a.data),
+	}
+}
+
+// providerLink is a link to a song, album, artist or playlist on a
+// provider, displayed as a button.
+type providerLink struct {
+	provider streamingproviders.Info
+	url      string
+
+	// approximate denotes that the link may not point to exactly what
+	// was converted (e.g., a different version of a song).
+	approximate bool
+}
+
+const (
+	// maxEmbedsPerMessage is the maximum number of embeds Discord allows
+	// in a single message.
+	maxEmbedsPerMessage = 10
+
+	// maxRowsPerMessage is the maximum number of action rows Discord
+	// allows in a single message.
+	maxRowsPerMessage = 5
+
+	// maxButtonsPerRow is the maximum number of buttons Discord allows in
+	// a single action row.
+	maxButtonsPerRow = 5
+
+	// approximateLabel is displayed on the buttons of approximate links.
+	approximateLabel = "≈"
+)
+
+// rows returns the buttons of the conversion wrapped in as many action
+// rows as required.
+func (c *conversion) rows() []discordgo.MessageComponent {
+	var rows []discordgo.MessageComponent
+	for chunk := range slices.Chunk(c.buttons, maxButtonsPerRow) {
+		buttons := make([]discordgo.MessageComponent, 0, len(chunk))
+		for i := range chunk {
+			b := linkButton(&chunk[i].provider, chunk[i].url)
+			if chunk[i].approximate {
+				b.Label = approximateLabel
+			}
+			buttons = append(buttons, b)
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	return rows
+}
+
+// applyGuildConfig removes the links to providers the guild doesn't want
+// shown and orders the rest using the guild's preferred order.
+func (c *conversion) applyGuildConfig(gc *GuildConfig) {
+	if len(gc.Providers) > 0 {
+		c.buttons = slices.DeleteFunc(c.buttons, func(l providerLink) bool {
+			return !slices.Contains(gc.Providers, l.provider.Identifier)
+		})
+	}
+
+	// Providers not in the preferred order go after those that are.
+	rank := func(l providerLink) int {
+		if i := slices.Index(gc.ButtonOrder, l.provider.Identifier); i != -1 {
+			return i
+		}
+		return len(gc.ButtonOrder)
+	}
+	slices.SortStableFunc(c.buttons, func(a, b providerLink) int {
+		return rank(a) - rank(b)
+	})
+}
+
+// buildMessages turns the provided conversions into as few messages as
+// possible while respecting Discord's limits on embeds and action rows
+// per message. Conversions are never split across messages. Each call
+// returns new files, so the messages can be built again to resend them.
+func buildMessages(cs []*conversion) []*discordgo.MessageSend {
+	var msgs []*discordgo.MessageSend
+	var cur *discordgo.MessageSend
+	for _, c := range cs {
+		rows := c.rows()
+		if cur == nil || len(cur.Embeds)+1 > maxEmbedsPerMessage || len(cur.Components)+len(rows) > maxRowsPerMessage {
+			cur = &discordgo.MessageSend{}
+			msgs = append(msgs, cur)
+		}
+
+		cur.Embeds = append(cur.Embeds, c.embed)
+		cur.Components = append(cur.Components, rows...)
+		for i := range c.files {
+			cur.Files = append(cur.Files, c.files[i].file())
+		}
+	}
+	return msgs
+}
+
+// linkButton returns a button linking to the provided URL, displayed
+// using the provider's emoji.
+func linkButton(pinfo *streamingproviders.Info, u string) discordgo.Button {
+	return discordgo.Button{
+		URL:   u,
+		Emoji: &pinfo.Emoji,
+		Style: discordgo.LinkButton,
+	}
+}
+
+// appendTimedOut appends a note listing the provided providers to an
+// embed's footer, if there are any. This lets users know that a provider
+// is missing because it was too slow, not because it doesn't have it.
+func appendTimedOut(footer []string, timedOut []streamingproviders.Info) []string {
+	if len(timedOut) == 0 {
+		return footer
+	}
+
+	names := make([]string, 0, len(timedOut))
+	for i := range timedOut {
+		names = append(names, timedOut[i].Name)
+	}
+	return append(footer, "No response from "+strings.Join(names, ", "))
+}
+
+// newSongConversion creates a conversion for a song and its
+// alternatives.
+func newSongConversion(song *streamingproviders.Song, alts *Alternatives[streamingproviders.Song],
+	author *discordgo.User) *conversion {
+	// Convert the duration into a human readable format.
+	duration := fmt.Sprintf("%d:%02d", song.Duration/60, song.Duration%60)
+
+	// Songs that can't be played in the provider's region aren't linked
+	// to, as the link wouldn't work for most users.
+	var found []*streamingproviders.Song
+	var restricted []string
+	for _, alt := range alts.Found {
+		if alt.RegionRestricted {
+			restricted = append(restricted, alt.Provider.Name)
+			continue
+		}
+		found = append(found, alt)
+	}
+
+	footer := []string{song.Provider.Name, "Duration " + duration, "Shared by @" + author.Username}
+	if slices.ContainsFunc(found, (*streamingproviders.Song).LowConfidence) {
+		footer = append(footer, approximateLabel+" Closest match, may be a different version")
+	}
+	if len(restricted) > 0 {
+		footer = append(footer, "Not available in your region on "+strings.Join(restricted, ", "))
+	}
+	footer = appendTimedOut(footer, alts.TimedOut)
+
+	c := &conversion{
+		embed: &discordgo.MessageEmbed{
+			Type:        discordgo.EmbedTypeRich,
+			Title:       song.Title,
+			Description: strings.Join(song.Artists, ", "),
+			URL:         song.ProviderURL,
+			Thumbnail: &discordgo.MessageEmbedThumbnail{
+				URL:    song.AlbumArtURL,
+				Height: 50,
+				Width:  50,
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: strings.Join(footer, " · "),
+			},
+		},
+	}
+
+	// Create a copy of alts with the original song. We want to show it at
+	// the end of the message.
+	songEmbeds := append([]*streamingproviders.Song{}, found...)
+	songEmbeds = append(songEmbeds, song)
+
+	for i := range songEmbeds {
+		alt := songEmbeds[i]
+		c.buttons = append(c.buttons, providerLink{
+			provider:    alt.Provider,
+			url:         alt.ProviderURL,
+			approximate: alt.LowConfidence(),
+		})
+	}
+
+	return c
+}
+
+// newAlbumConversion creates a conversion for an album and its
+// alternatives.
+func newAlbumConversion(album *streamingproviders.Album, alts *Alternatives[streamingproviders.Album],
+	author *discordgo.User) *conversion {
+	footer := []string{album.Provider.Name, "Album"}
+	if album.TrackCount > 0 {
+		footer = append(footer, fmt.Sprintf("%d tracks", album.TrackCount))
+	}
+	if album.ReleaseDate != "" {
+		footer = append(footer, "Released "+album.ReleaseDate)
+	}
+	footer = append(footer, "Shared by @"+author.Username)
+	footer = appendTimedOut(footer, alts.TimedOut)
+
+	c := &conversion{
+		embed: &discordgo.MessageEmbed{
+			Type:        discordgo.EmbedTypeRich,
+			Title:       album.Title,
+			Description: strings.Join(album.Artists, ", "),
+			URL:         album.ProviderURL,
+			Thumbnail: &discordgo.MessageEmbedThumbnail{
+				URL:    album.ArtworkURL,
+				Height: 50,
+				Width:  50,
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: strings.Join(footer, " · "),
+			},
+		},
+	}
+
+	// Like songs, the original album is shown at the end.
+	for _, alt := range append(append([]*streamingproviders.Album{}, alts.Found...), album) {
+		c.buttons = append(c.buttons, providerLink{provider: alt.Provider, url: alt.ProviderURL})
+	}
+
+	return c
+}
+
+// newArtistConversion creates a conversion for an artist and its
+// alternatives.
+func newArtistConversion(artist *streamingproviders.Artist, alts *Alternatives[streamingproviders.Artist],
+	author *discordgo.User) *conversion {
+	footer := []string{artist.Provider.Name, "Artist", "Shared by @" + author.Username}
+	footer = appendTimedOut(footer, alts.TimedOut)
+
+	c := &conversion{
+		embed: &discordgo.MessageEmbed{
+			Type:  discordgo.EmbedTypeRich,
+			Title: artist.Name,
+			URL:   artist.ProviderURL,
+			Thumbnail: &discordgo.MessageEmbedThumbnail{
+				URL:    artist.ImageURL,
+				Height: 50,
+				Width:  50,
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: strings.Join(footer, " · "),
+			},
+		},
+	}
+
+	// Like songs, the original artist is shown at the end.
+	for _, alt := range append(append([]*streamingproviders.Artist{}, alts.Found...), artist) {
+		c.buttons = append(c.buttons, providerLink{provider: alt.Provider, url: alt.ProviderURL})
+	}
+
+	return c
+}
+
+// newPlaylistConversion creates a conversion for a playlist, summarizing
+// how many of its songs were found on each provider. JSON and CSV
+// reports of unmatched songs are attached if any songs couldn't be found.
+func newPlaylistConversion(pl *streamingproviders.Playlist, report *PlaylistReport,
+	author *discordgo.User) (*conversion, error) {
+	var desc strings.Builder
+	if pl.Owner != "" {
+		fmt.Fprintf(&desc, "By %s\n\n", pl.Owner)
+	}
+
+	var unmatched bool
+	for _, pr := range report.Providers {
+		fmt.Fprintf(&desc, "%d/%d tracks found on %s\n", pr.Found, report.Total, pr.Provider.Name)
+		if len(pr.Unmatched) > 0 {
+			unmatched = true
+		}
+	}
+
+	c := &conversion{
+		embed: &discordgo.MessageEmbed{
+			Type:        discordgo.EmbedTypeRich,
+			Title:       pl.Name,
+			Description: desc.String(),
+			URL:         pl.ProviderURL,
+			Thumbnail: &discordgo.MessageEmbedThumbnail{
+				URL:    pl.ImageURL,
+				Height: 50,
+				Width:  50,
+			},
+			Footer: &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("%s · Playlist · %d tracks · Shared by @%s", pl.Provider.Name, report.Total, author.Username),
+			},
+		},
+		// Playlists only exist on the provider they were created on.
+		buttons: []providerLink{{provider: pl.Provider, url: pl.ProviderURL}},
+	}
+
+	if unmatched {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal playlist report: %w", err)
+		}
+
+		csv, err := report.CSV()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CSV playlist report: %w", err)
+		}
+
+		c.files = append(c.files,
+			attachment{name: "unmatched-tracks.json", contentType: "application/json", data: b},
+			attachment{name: "unmatched-tracks.csv", contentType: "text/csv", data: csv},
+		)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,240 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/settings"
+)
+
+// ChannelMode controls how miku behaves in a watched channel.
+type ChannelMode string
+
+const (
+	// ChannelModeDelete converts every message containing a music link,
+	// then deletes the original message.
+	ChannelModeDelete ChannelMode = "delete"
+
+	// ChannelModeKeep converts every message containing a music link in
+	// reply to it, keeping the original message.
+	ChannelModeKeep ChannelMode = "keep"
+
+	// ChannelModeMention only converts messages that mention miku, in
+	// reply to them, keeping the original message.
+	ChannelModeMention ChannelMode = "mention"
+)
+
+// ChannelModes contains all valid ChannelMode values.
+var ChannelModes = []ChannelMode{ChannelModeDelete, ChannelModeKeep, ChannelModeMention}
+
+// Valid returns true if the mode is a known ChannelMode.
+func (m ChannelMode) Valid() bool {
+	return slices.Contains(ChannelModes, m)
+}
+
+// GuildConfig contains the configuration for a single guild.
+type GuildConfig struct {
+	// Channels maps the IDs of the channels to watch to how miku should
+	// behave in them.
+	Channels map[string]ChannelMode `json:"channels,omitempty"`
+
+	// Providers contains the identifiers of the providers to show links
+	// for. All providers are shown if empty.
+	Providers []string `json:"providers,omitempty"`
+
+	// ButtonOrder contains provider identifiers in the order their links
+	// should be shown in. Providers not in the list are shown after, in
+	// the default order.
+	ButtonOrder []string `json:"button_order,omitempty"`
+
+	// DeleteOriginals controls if original messages are deleted in
+	// channels using ChannelModeDelete. Defaults to true.
+	DeleteOriginals *bool `json:"delete_originals,omitempty"`
+
+	// SuppressEmbeds, if set, hides the embeds of messages miku replies
+	// to instead of deleting, as they duplicate the conversions.
+	SuppressEmbeds bool `json:"suppress_embeds,omitempty"`
+
+	// RepostWithWebhook, if set, reposts messages in channels using
+	// ChannelModeDelete through a webhook using the author's name and
+	// avatar, instead of quoting them.
+	RepostWithWebhook bool `json:"repost_with_webhook,omitempty"`
+
+	// AppleMusicStorefront is the Apple Music storefront (e.g., "gb")
+	// used when searching Apple Music. Defaults to "us".
+	AppleMusicStorefront string `json:"apple_music_storefront,omitempty"`
+}
+
+// shouldDeleteOriginals returns if original messages should be deleted
+// in channels using ChannelModeDelete.
+func (gc *GuildConfig) shouldDeleteOriginals() bool {
+	return gc.DeleteOriginals == nil || *gc.DeleteOriginals
+}
+
+// clone returns a deep copy of the configuration.
+func (gc *GuildConfig) clone() *GuildConfig {
+	c := *gc
+	c.Channels = maps.Clone(gc.Channels)
+	c.Providers = slices.Clone(gc.Providers)
+	c.ButtonOrder = slices.Clone(gc.ButtonOrder)
+	if gc.DeleteOriginals != nil {
+		v := *gc.DeleteOriginals
+		c.DeleteOriginals = &v
+	}
+	return &c
+}
+
+// LoadGuildConfigs reads guild configuration from the JSON file at the
+// provided path. The file maps guild IDs to their configuration, e.g.:
+//
+//	{"123": {"channels": {"456": "delete", "789": "mention"}}}
+func LoadGuildConfigs(path string) (map[string]*GuildConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var guilds map[string]*GuildConfig
+	if err := json.Unmarshal(b, &guilds); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	for guildID, gc := range guilds {
+		for channelID, mode := range gc.Channels {
+			if !mode.Valid() {
+				return nil, fmt.Errorf("invalid mode %q for channel %s in guild %s", mode, channelID, guildID)
+			}
+		}
+	}
+
+	return guilds, nil
+}
+
+// guilds contains the configuration of every guild. Configuration is
+// read from Config.Guilds, unless it has been changed at runtime through
+// commands, in which case it is read from Config.Settings (if set). It
+// is safe for concurrent use.
+type guilds struct {
+	mu  sync.Mutex
+	log *log.Logger
+
+	// defaults contains the configuration provided through Config.Guilds.
+	defaults map[string]*GuildConfig
+
+	// store, if set, persists configuration changed at runtime.
+	store *settings.Store
+
+	// changed contains configuration changed at runtime, keyed by guild
+	// ID. This also acts as a cache for store.
+	changed map[string]*GuildConfig
+}
+
+// newGuilds creates a guilds from the provided configuration.
+func newGuilds(conf *Config, logger *log.Logger) *guilds {
+	return &guilds{
+		log:      logger,
+		defaults: conf.Guilds,
+		store:    conf.Settings,
+		changed:  make(map[string]*GuildConfig),
+	}
+}
+
+// defaultConfig returns a copy of the configuration of the provided
+// guild as provided through Config.Guilds.
+func (g *guilds) defaultConfig(guildID string) *GuildConfig {
+	if gc, ok := g.defaults[guildID]; ok && gc != nil {
+		return gc.clone()
+	}
+	return &GuildConfig{}
+}
+
+// lookup returns the current configuration of the provided guild. The
+// returned value must not be modified. g.mu must be held.
+func (g *guilds) lookup(guildID string) *GuildConfig {
+	if gc, ok := g.changed[guildID]; ok {
+		return gc
+	}
+
+	gc := g.defaultConfig(guildID)
+	if g.store != nil {
+		var stored GuildConfig
+		switch err := g.store.Get(guildID, &stored); {
+		case err == nil:
+			gc = &stored
+		case !errors.Is(err, settings.ErrNotFound):
+			g.log.With("err", err, "guild.id", guildID).Warn("failed to read guild settings, using defaults")
+			return gc // Don't cache, so that we try again next time.
+		}
+	}
+
+	g.changed[guildID] = gc
+	return gc
+}
+
+// get returns a copy of the current configuration of the provided guild.
+func (g *guilds) get(guildID string) *GuildConfig {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.lookup(guildID).clone()
+}
+
+// update calls fn with a copy of the current configuration of the
+// provided guild and, if it doesn't return an error, stores the result.
+func (g *guilds) update(guildID string, fn func(gc *GuildConfig) error) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gc := g.lookup(guildID).clone()
+	if err := fn(gc); err != nil {
+		return err
+	}
+
+	if g.store != nil {
+		if err := g.store.Set(guildID, gc); err != nil {
+			return fmt.Errorf("failed to save guild settings: %w", err)
+		}
+	}
+
+	g.changed[guildID] = gc
+	return nil
+}
+
+// reset discards all configuration of the provided guild changed at
+// runtime.
+func (g *guilds) reset(guildID string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.store != nil {
+		if err := g.store.Delete(guildID); err != nil {
+			return fmt.Errorf("failed to delete guild settings: %w", err)
+		}
+	}
+
+	delete(g.changed, guildID)
+	return nil
+}
@@ -0,0 +1,259 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jaredallard/miku/internal/streamingproviders/applemusic"
+)
+
+// guildSetting is a GuildConfig field that can be changed through the
+// /miku config command.
+type guildSetting struct {
+	// name is the name of the setting, as shown to users.
+	name string
+
+	// description explains the setting and the format of its value.
+	description string
+
+	// get returns the value of the setting formatted for users.
+	get func(gc *GuildConfig) string
+
+	// set parses the provided value and sets the setting to it.
+	set func(gc *GuildConfig, v string) error
+
+	// reset sets the setting back to its value in def.
+	reset func(gc, def *GuildConfig)
+}
+
+// channelSettingRegexp matches a single channel in the value of the
+// "channels" setting (e.g., <#123>=delete).
+var channelSettingRegexp = regexp.MustCompile(`^<?#?(\d+)>?=(\w+)$`)
+
+// guildSettings returns every setting that can be changed through the
+// /miku config command.
+func (h *Handler) guildSettings() []guildSetting {
+	return []guildSetting{{
+		name:        "channels",
+		description: "Channels to watch, e.g. #music=delete #general=mention",
+		get: func(gc *GuildConfig) string {
+			if len(gc.Channels) == 0 {
+				return "none"
+			}
+			return formatChannels(gc.Channels)
+		},
+		set: func(gc *GuildConfig, v string) error {
+			chs := make(map[string]ChannelMode)
+			for _, field := range strings.Fields(v) {
+				matches := channelSettingRegexp.FindStringSubmatch(field)
+				if matches == nil {
+					return fmt.Errorf("invalid channel %q, expected #channel=mode", field)
+				}
+
+				mode := ChannelMode(matches[2])
+				if !mode.Valid() {
+					return fmt.Errorf("invalid mode %q, expected one of %v", mode, ChannelModes)
+				}
+				chs[matches[1]] = mode
+			}
+			gc.Channels = chs
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.Channels = def.Channels },
+	}, {
+		name:        "providers",
+		description: "Providers to show links for, e.g. spotify,applemusic or all",
+		get: func(gc *GuildConfig) string {
+			if len(gc.Providers) == 0 {
+				return "all"
+			}
+			return strings.Join(gc.Providers, ", ")
+		},
+		set: func(gc *GuildConfig, v string) error {
+			providers, err := h.parseProviders(v)
+			if err != nil {
+				return err
+			}
+			gc.Providers = providers
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.Providers = def.Providers },
+	}, {
+		name:        "button_order",
+		description: "Order of the provider links, e.g. applemusic,spotify or default",
+		get: func(gc *GuildConfig) string {
+			if len(gc.ButtonOrder) == 0 {
+				return "default"
+			}
+			return strings.Join(gc.ButtonOrder, ", ")
+		},
+		set: func(gc *GuildConfig, v string) error {
+			if v == "default" {
+				gc.ButtonOrder = nil
+				return nil
+			}
+
+			order, err := h.parseProviders(v)
+			if err != nil {
+				return err
+			}
+			gc.ButtonOrder = order
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.ButtonOrder = def.ButtonOrder },
+	}, {
+		name:        "delete_originals",
+		description: "If messages in channels using the delete mode are deleted, true or false",
+		get: func(gc *GuildConfig) string {
+			return strconv.FormatBool(gc.shouldDeleteOriginals())
+		},
+		set: func(gc *GuildConfig, v string) error {
+			b, err := parseBool(v)
+			if err != nil {
+				return err
+			}
+			gc.DeleteOriginals = &b
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.DeleteOriginals = def.DeleteOriginals },
+	}, {
+		name:        "suppress_embeds",
+		description: "If link previews are hidden on messages miku replies to, true or false",
+		get: func(gc *GuildConfig) string {
+			return strconv.FormatBool(gc.SuppressEmbeds)
+		},
+		set: func(gc *GuildConfig, v string) error {
+			b, err := parseBool(v)
+			if err != nil {
+				return err
+			}
+			gc.SuppressEmbeds = b
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.SuppressEmbeds = def.SuppressEmbeds },
+	}, {
+		name:        "repost_with_webhook",
+		description: "If deleted messages are reposted as their author using a webhook, true or false",
+		get: func(gc *GuildConfig) string {
+			return strconv.FormatBool(gc.RepostWithWebhook)
+		},
+		set: func(gc *GuildConfig, v string) error {
+			b, err := parseBool(v)
+			if err != nil {
+				return err
+			}
+			gc.RepostWithWebhook = b
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.RepostWithWebhook = def.RepostWithWebhook },
+	}, {
+		name:        "apple_music_storefront",
+		description: "Apple Music storefront to search in, e.g. gb",
+		get: func(gc *GuildConfig) string {
+			if gc.AppleMusicStorefront == "" {
+				return h.defaultStorefront() + " (default)"
+			}
+			return gc.AppleMusicStorefront
+		},
+		set: func(gc *GuildConfig, v string) error {
+			v = strings.ToLower(v)
+			if !applemusic.ValidStorefront(v) {
+				return fmt.Errorf("invalid storefront %q, expected a two letter country code", v)
+			}
+			gc.AppleMusicStorefront = v
+			return nil
+		},
+		reset: func(gc, def *GuildConfig) { gc.AppleMusicStorefront = def.AppleMusicStorefront },
+	}}
+}
+
+// guildSetting returns the setting with the provided name, or false if
+// it doesn't exist.
+func (h *Handler) guildSetting(name string) (guildSetting, bool) {
+	settings := h.guildSettings()
+	i := slices.IndexFunc(settings, func(s guildSetting) bool { return s.name == name })
+	if i == -1 {
+		return guildSetting{}, false
+	}
+	return settings[i], true
+}
+
+// parseProviders parses a list of provider identifiers separated by
+// commas or spaces. "all" returns an empty list. Only enabled providers
+// are accepted.
+func (h *Handler) parseProviders(v string) ([]string, error) {
+	if v == "all" {
+		return nil, nil
+	}
+
+	enabled := make([]string, 0, len(h.sps))
+	for _, sp := range h.sps {
+		enabled = append(enabled, sp.Info().Identifier)
+	}
+
+	ids := strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no providers provided")
+	}
+	providers := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(enabled, id) {
+			return nil, fmt.Errorf("unknown provider %q, expected one of %v", id, enabled)
+		}
+		if !slices.Contains(providers, id) {
+			providers = append(providers, id)
+		}
+	}
+	return providers, nil
+}
+
+// parseBool parses a boolean setting value.
+func parseBool(v string) (bool, error) {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q, expected true or false", v)
+	}
+	return b, nil
+}
+
+// formatChannels formats the provided channels for users.
+func formatChannels(chs map[string]ChannelMode) string {
+	formatted := make([]string, 0, len(chs))
+	for _, channelID := range slices.Sorted(maps.Keys(chs)) {
+		formatted = append(formatted, fmt.Sprintf("<#%s> (%s)", channelID, chs[channelID]))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// defaultStorefront returns the Apple Music storefront searched in by
+// guilds that haven't set one.
+func (h *Handler) defaultStorefront() string {
+	for _, sp := range h.sps {
+		if ap, ok := sp.(*applemusic.Provider); ok {
+			return ap.Storefront(context.Background())
+		}
+	}
+	return applemusic.DefaultStorefront
+}
@@ -25,16 +25,34 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/cache"
+	"github.com/jaredallard/miku/internal/settings"
+	"github.com/jaredallard/miku/internal/shortlinks"
 	"github.com/jaredallard/miku/internal/streamingproviders"
 	"github.com/jaredallard/miku/internal/streamingproviders/applemusic"
+	"github.com/jaredallard/miku/internal/streamingproviders/deezer"
 	"github.com/jaredallard/miku/internal/streamingproviders/spotify"
+	"github.com/jaredallard/miku/internal/streamingproviders/tidal"
+	"github.com/jaredallard/miku/internal/streamingproviders/youtubemusic"
 	"mvdan.cc/xurls/v2"
 )
 
+const (
+	// directMessageRateLimit is the number of direct messages containing
+	// links each user can send per directMessageRateLimitWindow.
+	directMessageRateLimit = 5
+
+	// directMessageRateLimitWindow is the window directMessageRateLimit
+	// applies to.
+	directMessageRateLimitWindow = time.Minute
+)
+
 // ErrFailedToFindOriginal is returned when a URL could not be matched
 // to a song on any provider.
 var ErrFailedToFindOriginal = errors.New("failed to find original song")
@@ -43,9 +61,29 @@ var ErrFailedToFindOriginal = errors.New("failed to find original song")
 //
 // TODO: move somewhere else
 type Config struct {
-	// ChannelID is the channel where the bot should listen to messages
-	// from.
+	// ChannelID, if set, is a channel where the bot should listen to
+	// messages from using ChannelModeDelete.
+	//
+	// Deprecated: Use Guilds instead.
 	ChannelID string
+
+	// Guilds maps guild IDs to their default configuration, which
+	// contains the channels the bot should listen to messages from. It
+	// can be changed at runtime through the /miku command.
+	Guilds map[string]*GuildConfig
+
+	// Settings, if set, persists guild configuration changed at runtime
+	// and the replies sent by miku.
+	// Otherwise, changes are lost on restart.
+	Settings *settings.Store
+
+	// ProviderTimeout is the maximum amount of time each provider is
+	// given to search for an alternative. Providers that don't respond in
+	// time are skipped. Defaults to DefaultProviderTimeout.
+	ProviderTimeout time.Duration
+
+	// Cache, if set, is used to cache song lookups and searches.
+	Cache *cache.Cache
 }
 
 // Handler contains the discord bot's configuration and the configured
@@ -55,6 +93,15 @@ type Handler struct {
 	log *log.Logger
 
 	sps []streamingproviders.Provider
+
+	guilds   *guilds
+	webhooks *webhooks
+	replies  *replies
+	resolver *shortlinks.Resolver
+
+	// dmLimiter limits how many direct messages each user can have
+	// converted.
+	dmLimiter *rateLimiter
 }
 
 // New creates a new handler with the default set of providers enabled.
@@ -64,11 +111,18 @@ func New(conf *Config, logger *log.Logger) *Handler {
 	enabledProviders := []func(context.Context, *log.Logger) (streamingproviders.Provider, error){
 		spotify.New,
 		applemusic.New,
+		tidal.New,
+		youtubemusic.New,
+		deezer.New,
 	}
 	for _, provider := range enabledProviders {
 		plog := logger.With()
 
 		sp, err := provider(context.Background(), plog)
+		if errors.Is(err, streamingproviders.ErrNotConfigured) {
+			logger.With("err", err).Info("provider not configured, it will be disabled")
+			continue
+		}
 		if err != nil {
 			logger.With("err", err).Fatal("failed to create provider")
 		}
@@ -85,160 +139,294 @@ func New(conf *Config, logger *log.Logger) *Handler {
 
 // NewWithProviders creates a new handler with the provided providers.
 func NewWithProviders(conf *Config, logger *log.Logger, sps []streamingproviders.Provider) *Handler {
-	return &Handler{conf, logger, sps}
+	return &Handler{
+		c:        conf,
+		log:      logger,
+		sps:      sps,
+		guilds:   newGuilds(conf, logger),
+		webhooks: &webhooks{byChannel: make(map[string]*discordgo.Webhook)},
+		replies:  newReplies(conf),
+		resolver: shortlinks.New(conf.Cache, logger),
+
+		dmLimiter: newRateLimiter(directMessageRateLimit, directMessageRateLimitWindow),
+	}
 }
 
 // EventHandler implements a [discordgo.EventHandler] for handling new
-// messages being sent.
+// messages being sent, both in guilds and directly to miku.
 func (h *Handler) EventHandler(s *discordgo.Session, m *discordgo.MessageCreate) {
 	ctx := context.Background()
-	if m.ChannelID != h.c.ChannelID {
-		return // Ignore things not in our channel.
-	}
-
 	if m.Author.Bot {
 		return // Ignore bots.
 	}
 
+	// Direct messages aren't sent in a guild. They're always converted
+	// and, as we can't delete them, replied to.
+	isDM := m.GuildID == ""
+
+	gc := &GuildConfig{}
+	mode := ChannelModeKeep
+	if !isDM {
+		gc = h.guilds.get(m.GuildID)
+
+		var ok bool
+		mode, ok = h.watchedChannelMode(s, gc, m.Message)
+		if !ok {
+			return // Ignore things not in our channels.
+		}
+
+		if mode == ChannelModeMention && !mentionsUser(m.Message, s.State.User) {
+			return // Only convert when asked to.
+		}
+	}
+
 	h.log.With("message.contents", m.Content).Debug("observed message")
 
-	urlx := xurls.Strict()
-	urls := urlx.FindAllString(m.Content, -1)
+	urls := extractURLs(m.Content)
 	if len(urls) == 0 {
 		h.log.Debug("no urls found in message")
 		return
 	}
 
+	if isDM && !h.dmLimiter.allow(m.Author.ID) {
+		h.log.With("user.id", m.Author.ID).Warn("user is sending direct messages too quickly, ignoring message")
+		if err := s.MessageReactionAdd(m.ChannelID, m.ID, "⏳"); err != nil {
+			h.log.With("err", err).Error("failed to add reaction")
+		}
+		return
+	}
+
 	h.log.With("urls", urls).Debug("found urls")
 
-	originalSong, alts, err := h.NewURL(ctx, urls[0])
-	if err != nil {
-		// If we're an error other than failing to find the original song at
-		// all, report it to the user.
-		if !errors.Is(err, ErrFailedToFindOriginal) {
-			if err := s.MessageReactionAdd(m.ChannelID, m.ID, "❌"); err != nil {
-				h.log.With("err", err).Error("failed to add reaction")
-			}
-			if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
-				Content:   fmt.Sprintf("```go\nFailed to process %q: %v\n```", urls[0], err),
-				Reference: m.Reference(),
-			}); err != nil {
-				h.log.With("err", err).Error("failed to notify user of failure reason")
+	var cs []*conversion
+	var convertedURLs []string
+	for _, u := range urls {
+		c, err := h.convertURL(ctx, gc, u, m.Author)
+		if err != nil {
+			// If we're an error other than failing to find the original song
+			// at all, report it to the user.
+			if !errors.Is(err, ErrFailedToFindOriginal) {
+				if err := s.MessageReactionAdd(m.ChannelID, m.ID, "❌"); err != nil {
+					h.log.With("err", err).Error("failed to add reaction")
+				}
+				if _, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+					Content:   fmt.Sprintf("```go\nFailed to process %q: %v\n```", u, err),
+					Reference: m.Reference(),
+				}); err != nil {
+					h.log.With("err", err).Error("failed to notify user of failure reason")
+				}
 			}
+
+			h.log.With("err", err, "url", u).Error("failed to handle url")
+			continue
 		}
 
-		h.log.With("err", err).Error("failed to handle url")
+		cs = append(cs, c)
+		convertedURLs = append(convertedURLs, u)
+	}
+	if len(cs) == 0 {
 		return
 	}
 
+	// Only remove the original message if nothing in it would be lost.
+	deleteOriginal := mode == ChannelModeDelete && gc.shouldDeleteOriginals() && len(convertedURLs) == len(urls)
+
+	// The first message of a thread (e.g., a forum post) shares its ID.
+	// Deleting it would leave the thread without a starting message.
+	if m.ID == m.ChannelID {
+		deleteOriginal = false
+	}
+
+	// Fall back to replying if we aren't allowed to delete it, otherwise
+	// we'd fail after already having sent the conversions.
+	if deleteOriginal && !h.hasPermission(s, m.ChannelID, discordgo.PermissionManageMessages) {
+		h.log.With("channel.id", m.ChannelID).Debug("missing Manage Messages permission, replying instead")
+		deleteOriginal = false
+	}
+
 	// Send a message back to the user.
-	if err := h.sendMessage(s, m, urls, originalSong, alts); err != nil {
+	sent, err := h.sendMessage(s, m, gc, convertedURLs, cs, deleteOriginal)
+	if err != nil {
 		h.log.With("err", err).Error("failed to send message")
 		return
 	}
+
+	// Keep track of our reply so that it can be kept up to date with the
+	// original message.
+	if !deleteOriginal {
+		h.rememberReply(m.Message, urls, sent)
+	}
+}
+
+// channelMode returns the mode of the provided channel, or false if it
+// isn't being watched.
+func (h *Handler) channelMode(gc *GuildConfig, channelID string) (ChannelMode, bool) {
+	if mode, ok := gc.Channels[channelID]; ok {
+		return mode, true
+	}
+
+	// The legacy single channel isn't associated with a guild.
+	if h.c.ChannelID != "" && channelID == h.c.ChannelID {
+		return ChannelModeDelete, true
+	}
+
+	return "", false
+}
+
+// mentionsUser returns true if the provided message mentions the
+// provided user.
+func mentionsUser(m *discordgo.Message, u *discordgo.User) bool {
+	if u == nil {
+		return false
+	}
+	return slices.ContainsFunc(m.Mentions, func(mu *discordgo.User) bool {
+		return mu.ID == u.ID
+	})
+}
+
+// extractURLs returns every URL in the provided message content.
+// Duplicates are removed, no reason to convert the same thing twice.
+// Order is preserved so that embeds match the order of the message.
+func extractURLs(content string) []string {
+	urls := xurls.Strict().FindAllString(content, -1)
+
+	seen := make(map[string]struct{}, len(urls))
+	return slices.DeleteFunc(urls, func(u string) bool {
+		_, ok := seen[u]
+		seen[u] = struct{}{}
+		return ok
+	})
 }
 
 // NewURL takes a URL and searches all enabled providers for it. It then
 // searches all provides (minus the one the song was found on) and
 // returns alternative streamingproviders where that song was found (the
-// alternatives).
+// alternatives). How each alternative was found is recorded in its
+// MatchMethod and MatchScore fields.
 //
 //nolint:gocritic // Why: Documented above.
 func (h *Handler) NewURL(ctx context.Context, urlStr string) (*streamingproviders.Song,
-	[]*streamingproviders.Song, error) {
-	originalSong, alts := h.findAlts(ctx, urlStr)
+	*Alternatives[streamingproviders.Song], error) {
+	originalSong := h.findOriginalSongByURL(ctx, urlStr)
 	if originalSong == nil {
 		return nil, nil, ErrFailedToFindOriginal
 	}
+	alts := h.findAlts(ctx, originalSong)
 	h.log.With(
 		"song.isrc", originalSong.ISRC,
 		"song.provider", originalSong.Provider.Identifier,
 		"song.title", originalSong.Title,
 		"song.artists", originalSong.Artists,
 	).Info("found original song")
-	if len(alts) == 0 {
-		return originalSong, nil, nil
-	}
 
-	for _, alt := range alts {
+	for _, alt := range alts.Found {
 		h.log.With(
 			"song.provider", alt.Provider.Identifier,
 			"song.title", alt.Title,
 			"song.artists", alt.Artists,
+			"song.match_method", alt.MatchMethod,
+			"song.match_score", alt.MatchScore,
 		).Info("found alternative")
 	}
 
 	return originalSong, alts, nil
 }
 
-// sendMessage sends a reply to the original message with information on
-// the current song as well as alternatives.
-func (h *Handler) sendMessage(s *discordgo.Session, m *discordgo.MessageCreate, urls []string,
-	song *streamingproviders.Song, alts []*streamingproviders.Song) error {
-	// Convert the duration into a human readable format.
-	duration := fmt.Sprintf("%d:%02d", song.Duration/60, song.Duration%60)
-
-	msg := &discordgo.MessageSend{
-		Embeds: []*discordgo.MessageEmbed{{
-			Type:        discordgo.EmbedTypeRich,
-			Title:       song.Title,
-			Description: strings.Join(song.Artists, ", "),
-			URL:         song.ProviderURL,
-			Thumbnail: &discordgo.MessageEmbedThumbnail{
-				URL:    song.AlbumArtURL,
-				Height: 50,
-				Width:  50,
-			},
-			Footer: &discordgo.MessageEmbedFooter{
-				Text: fmt.Sprintf("%s · Duration %s · Shared by @%s", song.Provider.Name, duration, m.Author.Username),
-			},
-		}},
-	}
-
-	// Remove URLs from the original message and see if there's still
-	// anything left. If so, we should send it with the message.
-	content := m.Content
-	for _, url := range urls {
-		content = strings.Replace(content, url, "", 1)
-	}
-	content = strings.TrimSpace(content)
-	content = strings.TrimSuffix(content, ":")
-
-	if content != "" {
-		msg.Content = fmt.Sprintf(" > %s: %s", m.Author.Mention(), content)
-	}
-
-	// Create a copy of alts with the original song. We want to show it at
-	// the end of the message.
-	songEmbeds := append([]*streamingproviders.Song{}, alts...)
-	songEmbeds = append(songEmbeds, song)
-
-	var row []discordgo.MessageComponent
-	for i := range songEmbeds {
-		alt := songEmbeds[i]
-		row = append(row, discordgo.Button{
-			URL:   alt.ProviderURL,
-			Emoji: &alt.Provider.Emoji,
-			Style: discordgo.LinkButton,
-		})
-	}
-
-	// We need to wrap the rows in an actionsrow component.
-	msg.Components = append(msg.Components, discordgo.ActionsRow{
-		Components: row,
-	})
+// sendMessage sends the provided conversions in reply to the original
+// message, splitting them across multiple messages if required. If
+// deleteOriginal is set, the original message's text (minus the
+// converted URLs) is reposted and the original message is deleted. The
+// text is reposted through a webhook impersonating the author if the
+// guild has enabled it, and quoted otherwise. If deleteOriginal isn't
+// set, the conversions are sent as a reply to the original message.
+// The messages sent by miku (not through a webhook) are returned.
+func (h *Handler) sendMessage(s *discordgo.Session, m *discordgo.MessageCreate, gc *GuildConfig, urls []string,
+	cs []*conversion, deleteOriginal bool) ([]*discordgo.Message, error) {
+	msgs := buildMessages(cs)
+
+	if deleteOriginal {
+		// Remove URLs from the original message and see if there's still
+		// anything left. If so, we should send it with the message.
+		content := m.Content
+		for _, url := range urls {
+			content = strings.Replace(content, url, "", 1)
+		}
+		content = strings.TrimSpace(content)
+		content = strings.TrimSuffix(content, ":")
 
-	// encode to JSON so we can debug it easier
-	b, err := json.Marshal(msg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		if gc.RepostWithWebhook && h.hasPermission(s, m.ChannelID, discordgo.PermissionManageWebhooks) {
+			n, err := h.repostAsAuthor(s, m, content, msgs)
+			if err == nil {
+				return nil, h.deleteOriginal(s, m)
+			}
+
+			// Webhooks require the Manage Webhooks permission, which we may
+			// not have. Only the messages that weren't reposted are sent,
+			// built again as the readers of their files may have been
+			// consumed.
+			h.log.With("err", err, "messages.sent", n).Warn("failed to repost message using webhook, falling back to quoting it")
+			msgs = buildMessages(cs)[n:]
+			if n > 0 {
+				// The text was sent with the first message.
+				content = ""
+			}
+		}
+
+		if content != "" {
+			msgs[0].Content = fmt.Sprintf(" > %s: %s", m.Author.Mention(), content)
+		}
+	} else {
+		// Reply without pinging the author, they're right there.
+		msgs[0].Reference = m.Reference()
+		msgs[0].AllowedMentions = &discordgo.MessageAllowedMentions{}
 	}
 
-	h.log.With("discord.message", string(b)).Debug("sending message")
-	if _, err := s.ChannelMessageSendComplex(m.ChannelID, msg); err != nil {
-		return fmt.Errorf("failed to send reply: %w", err)
+	sent := make([]*discordgo.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		// encode to JSON so we can debug it easier
+		b, err := json.Marshal(msg)
+		if err != nil {
+			return sent, fmt.Errorf("failed to marshal message: %w", err)
+		}
+
+		h.log.With("discord.message", string(b)).Debug("sending message")
+		sm, err := s.ChannelMessageSendComplex(m.ChannelID, msg)
+		if err != nil {
+			return sent, fmt.Errorf("failed to send reply: %w", err)
+		}
+		sent = append(sent, sm)
+	}
+
+	if !deleteOriginal {
+		if gc.SuppressEmbeds {
+			h.suppressEmbeds(s, m)
+		}
+		return sent, nil
 	}
 
+	return sent, h.deleteOriginal(s, m)
+}
+
+// suppressEmbeds hides the embeds Discord generated for the links in the
+// provided message, as they duplicate the conversions. This is best
+// effort, as it requires the Manage Messages permission.
+func (h *Handler) suppressEmbeds(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !h.hasPermission(s, m.ChannelID, discordgo.PermissionManageMessages) {
+		h.log.With("channel.id", m.ChannelID).Debug("missing Manage Messages permission, not suppressing embeds")
+		return
+	}
+
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:      m.ID,
+		Channel: m.ChannelID,
+		Flags:   m.Flags | discordgo.MessageFlagsSuppressEmbeds,
+	}); err != nil {
+		h.log.With("err", err).Warn("failed to suppress embeds of original message")
+	}
+}
+
+// deleteOriginal deletes the provided message.
+func (h *Handler) deleteOriginal(s *discordgo.Session, m *discordgo.MessageCreate) error {
 	h.log.With("discord.message", m.Reference().MessageID).Debug("deleting original message")
 	if err := s.ChannelMessageDelete(m.ChannelID, m.ID); err != nil {
 		return fmt.Errorf("failed to delete original message: %w", err)
@@ -247,12 +435,94 @@ func (h *Handler) sendMessage(s *discordgo.Session, m *discordgo.MessageCreate,
 	return nil
 }
 
+// convertURL takes a URL and converts it into a conversion for the song,
+// album, artist or playlist it points to, using the provided guild
+// configuration. If the URL doesn't point to any of those on any
+// provider, ErrFailedToFindOriginal is returned.
+func (h *Handler) convertURL(ctx context.Context, gc *GuildConfig, urlStr string,
+	author *discordgo.User) (*conversion, error) {
+	// Short links don't match any provider, so expand them first.
+	resolved, err := h.resolver.Resolve(ctx, urlStr)
+	if err != nil {
+		h.log.With("err", err, "url", urlStr).Warn("failed to resolve short link")
+		return nil, ErrFailedToFindOriginal
+	}
+	if resolved != urlStr {
+		h.log.With("url", urlStr, "resolved", resolved).Debug("resolved short link")
+	}
+
+	c, err := h.convertURLUnfiltered(withGuildConfig(ctx, gc), resolved, author)
+	if err != nil {
+		return nil, err
+	}
+
+	c.applyGuildConfig(gc)
+	return c, nil
+}
+
+// convertURLUnfiltered implements convertURL, without applying the
+// guild's provider preferences to the result.
+func (h *Handler) convertURLUnfiltered(ctx context.Context, urlStr string, author *discordgo.User) (*conversion, error) {
+	song, alts, err := h.NewURL(ctx, urlStr)
+	if err == nil {
+		return newSongConversion(song, alts, author), nil
+	}
+	if !errors.Is(err, ErrFailedToFindOriginal) {
+		return nil, err
+	}
+
+	album, altAlbums, err := h.NewAlbumURL(ctx, urlStr)
+	if err == nil {
+		return newAlbumConversion(album, altAlbums, author), nil
+	}
+	if !errors.Is(err, ErrFailedToFindOriginal) {
+		return nil, err
+	}
+
+	artist, altArtists, err := h.NewArtistURL(ctx, urlStr)
+	if err == nil {
+		return newArtistConversion(artist, altArtists, author), nil
+	}
+	if !errors.Is(err, ErrFailedToFindOriginal) {
+		return nil, err
+	}
+
+	pl, report, err := h.NewPlaylistURL(ctx, urlStr)
+	if err != nil {
+		return nil, err
+	}
+	return newPlaylistConversion(pl, report, author)
+}
+
+// withGuildConfig returns a context configuring providers with the
+// provided guild configuration.
+func withGuildConfig(ctx context.Context, gc *GuildConfig) context.Context {
+	if gc.AppleMusicStorefront != "" {
+		ctx = applemusic.WithStorefront(ctx, gc.AppleMusicStorefront)
+	}
+	return ctx
+}
+
 // findOriginalSongByURL iterates over all enabled providers and returns
 // the first song that can be found on a provider. This function will
 // return nil if no song can be found.
 //
 // !!! IMPORTANT: Can return nil. See function definition.
 func (h *Handler) findOriginalSongByURL(ctx context.Context, urlStr string) *streamingproviders.Song {
+	return findByURL(ctx, h, "song", urlStr, func(ctx context.Context, sp streamingproviders.Provider,
+		u *url.URL) (*streamingproviders.Song, error) {
+		return h.lookupSongByURL(ctx, sp, u)
+	})
+}
+
+// findByURL iterates over all enabled providers and returns the first
+// non-error result of calling lookup with a provider that handles the
+// provided URL. kind is used for logging (e.g., "song"). This function
+// will return nil if nothing can be found.
+//
+// !!! IMPORTANT: Can return nil. See function definition.
+func findByURL[T any](ctx context.Context, h *Handler, kind, urlStr string,
+	lookup func(context.Context, streamingproviders.Provider, *url.URL) (*T, error)) *T {
 	for _, sp := range h.sps {
 		pinfo := sp.Info()
 		plog := h.log.With("provider.id", pinfo.Identifier)
@@ -264,53 +534,31 @@ func (h *Handler) findOriginalSongByURL(ctx context.Context, urlStr string) *str
 		}
 
 		// If our provider has a hostname, make sure the URL matches it.
-		if pinfo.URLHostname != "" && u.Hostname() != pinfo.URLHostname {
+		if !pinfo.MatchesHostname(u.Hostname()) {
 			plog.Debug("url doesn't match provider's hostname")
 			continue
 		}
 
-		plog.Debug("looking for song via URL")
+		plog.Debugf("looking for %s via URL", kind)
 
-		song, err := sp.LookupSongByURL(ctx, u)
+		v, err := lookup(ctx, sp, u)
 		if err == nil { // Found it.
-			plog.Info("found song")
-			return song
+			plog.Infof("found %s", kind)
+			return v
 		}
 
-		plog.With("err", err).Debug("provider failed to lookup song")
+		plog.With("err", err).Debugf("provider failed to lookup %s", kind)
 	}
 
 	// Didn't find it after searching all enabled providers.
 	return nil
 }
 
-// findAlts takes a URL and returns a list of all known songs for that
-// URL across enabled providers.
-//
-//nolint:gocritic // Why: Documented above.
-func (h *Handler) findAlts(ctx context.Context, urlStr string) (*streamingproviders.Song, []*streamingproviders.Song) {
-	song := h.findOriginalSongByURL(ctx, urlStr)
-	if song == nil {
-		return nil, nil
-	}
-
-	// Search all of the providers (minus the one we found it on) for the
-	// song and return all of the results.
-	var alts []*streamingproviders.Song
-	for _, sp := range h.sps {
-		if sp.Info().Identifier == song.Provider.Identifier {
-			continue
-		}
-
-		h.log.With("provider.id", sp.Info().Identifier).Debug("searching for alternative")
-		alt, err := sp.Search(ctx, song)
-		if err != nil {
-			h.log.With("err", err).Debug("failed to search for song")
-			continue
-		}
-
-		alts = append(alts, alt)
-	}
-
-	return song, alts
+// findAlts takes a song and searches all enabled providers, excluding
+// the provider it came from, for it.
+func (h *Handler) findAlts(ctx context.Context, song *streamingproviders.Song) *Alternatives[streamingproviders.Song] {
+	return searchAlts(ctx, h, "song", song.Provider.Identifier, func(ctx context.Context,
+		sp streamingproviders.Provider) (*streamingproviders.Song, error) {
+		return h.searchSong(ctx, sp, song)
+	})
 }
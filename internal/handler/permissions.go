@@ -0,0 +1,68 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// hasPermission returns true if miku has the provided permission in the
+// provided channel. Permissions are read from the state cache, which
+// requires the Guilds intent. If they can't be determined, false is
+// returned so that we never attempt anything destructive we may not be
+// allowed to do.
+func (h *Handler) hasPermission(s *discordgo.Session, channelID string, perm int64) bool {
+	if s.State == nil || s.State.User == nil {
+		return false
+	}
+
+	// Threads don't have permissions of their own, they inherit them from
+	// the channel they were created in.
+	if parentID, ok := h.threadParentID(s, channelID); ok {
+		channelID = parentID
+	}
+
+	perms, err := s.State.UserChannelPermissions(s.State.User.ID, channelID)
+	if err != nil {
+		h.log.With("err", err, "channel.id", channelID).Debug("failed to determine permissions")
+		return false
+	}
+	return perms&perm == perm
+}
+
+// GuildCreateHandler implements a [discordgo.EventHandler] for guilds
+// becoming available, which happens at startup and when miku joins a
+// guild. It warns about watched channels where miku doesn't have the
+// permissions required to delete messages, in which case it will reply
+// to them instead.
+func (h *Handler) GuildCreateHandler(s *discordgo.Session, g *discordgo.GuildCreate) {
+	gc := h.guilds.get(g.ID)
+	for _, channelID := range slices.Sorted(maps.Keys(gc.Channels)) {
+		if gc.Channels[channelID] != ChannelModeDelete || !gc.shouldDeleteOriginals() {
+			continue
+		}
+
+		if !h.hasPermission(s, channelID, discordgo.PermissionManageMessages) {
+			h.log.With("guild.id", g.ID, "channel.id", channelID).
+				Warn("missing Manage Messages permission in channel, will reply to messages instead of deleting them")
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+const (
+	// maxPlaylistSongs is the maximum number of songs in a playlist that
+	// will be searched for on other providers.
+	maxPlaylistSongs = 100
+
+	// playlistConcurrency is the maximum number of songs in a playlist
+	// that are searched for at the same time.
+	playlistConcurrency = 4
+)
+
+// playlistSkippedProviders contains the identifiers of the providers
+// playlist songs aren't searched for on. Searching YouTube costs 100
+// units of its 10,000 daily quota, so a single playlist could use up
+// most of it.
+var playlistSkippedProviders = []string{"youtubemusic"}
+
+// errPlaylistsNotSupported is returned when a provider doesn't implement
+// streamingproviders.PlaylistProvider.
+var errPlaylistsNotSupported = errors.New("provider does not support playlists")
+
+// errSkippedForPlaylists is returned when searching for a playlist song
+// on a provider in playlistSkippedProviders.
+var errSkippedForPlaylists = errors.New("provider is not searched for playlist songs")
+
+// PlaylistReport describes how many of a playlist's songs could be found
+// on each of the other enabled providers.
+type PlaylistReport struct {
+	// Playlist is the name of the playlist.
+	Playlist string `json:"playlist"`
+
+	// URL is the URL of the playlist.
+	URL string `json:"url"`
+
+	// Total is the number of songs in the playlist that were searched
+	// for.
+	Total int `json:"total"`
+
+	// Providers contains a report for each provider the songs were
+	// searched for on.
+	Providers []*ProviderPlaylistReport `json:"providers"`
+}
+
+// ProviderPlaylistReport describes how many of a playlist's songs could
+// be found on a single provider.
+type ProviderPlaylistReport struct {
+	// Provider is the provider the songs were searched for on.
+	Provider streamingproviders.Info `json:"-"`
+
+	// ProviderID is the identifier of Provider.
+	ProviderID string `json:"provider"`
+
+	// Found is the number of songs that were found on the provider.
+	Found int `json:"found"`
+
+	// Unmatched are the songs that couldn't be found on the provider.
+	Unmatched []*UnmatchedSong `json:"unmatched"`
+}
+
+// UnmatchedSong is a song from a playlist that couldn't be found on a
+// provider.
+type UnmatchedSong struct {
+	Title   string   `json:"title"`
+	Artists []string `json:"artists"`
+	ISRC    string   `json:"isrc"`
+	URL     string   `json:"url"`
+}
+
+// NewPlaylistURL takes a URL and searches all enabled providers for the
+// playlist it points to. Each song in the playlist is then searched for
+// on the other providers, returning a report of which songs could be
+// found where.
+//
+//nolint:gocritic // Why: Documented above.
+func (h *Handler) NewPlaylistURL(ctx context.Context, urlStr string) (*streamingproviders.Playlist,
+	*PlaylistReport, error) {
+	pl := findByURL(ctx, h, "playlist", urlStr, func(ctx context.Context, sp streamingproviders.Provider,
+		u *url.URL) (*streamingproviders.Playlist, error) {
+		pp, ok := sp.(streamingproviders.PlaylistProvider)
+		if !ok {
+			return nil, errPlaylistsNotSupported
+		}
+		return pp.LookupPlaylistByURL(ctx, u, maxPlaylistSongs)
+	})
+	if pl == nil {
+		return nil, nil, ErrFailedToFindOriginal
+	}
+	h.log.With(
+		"playlist.provider", pl.Provider.Identifier,
+		"playlist.name", pl.Name,
+		"playlist.songs", len(pl.Songs),
+	).Info("found original playlist")
+
+	// Search for every song, bounding how many are searched for at once
+	// to avoid hitting provider rate limits.
+	songAlts := make([]*Alternatives[streamingproviders.Song], len(pl.Songs))
+	sem := make(chan struct{}, playlistConcurrency)
+	var wg sync.WaitGroup
+	for i, song := range pl.Songs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			songAlts[i] = searchAlts(ctx, h, "song", song.Provider.Identifier, func(ctx context.Context,
+				sp streamingproviders.Provider) (*streamingproviders.Song, error) {
+				if slices.Contains(playlistSkippedProviders, sp.Info().Identifier) {
+					return nil, errSkippedForPlaylists
+				}
+				return h.searchSong(ctx, sp, song)
+			})
+		}()
+	}
+	wg.Wait()
+
+	report := &PlaylistReport{
+		Playlist: pl.Name,
+		URL:      pl.ProviderURL,
+		Total:    len(pl.Songs),
+	}
+	for _, sp := range h.sps {
+		pinfo := sp.Info()
+		if pinfo.Identifier == pl.Provider.Identifier || slices.Contains(playlistSkippedProviders, pinfo.Identifier) {
+			continue
+		}
+
+		pr := &ProviderPlaylistReport{Provider: pinfo, ProviderID: pinfo.Identifier}
+		for i, song := range pl.Songs {
+			found := false
+			for _, alt := range songAlts[i].Found {
+				if alt.Provider.Identifier == pinfo.Identifier {
+					// Songs that can't be played in the provider's region
+					// would be missing from a copy of the playlist.
+					found = !alt.RegionRestricted
+					break
+				}
+			}
+
+			if found {
+				pr.Found++
+				continue
+			}
+
+			pr.Unmatched = append(pr.Unmatched, &UnmatchedSong{
+				Title:   song.Title,
+				Artists: song.Artists,
+				ISRC:    song.ISRC,
+				URL:     song.ProviderURL,
+			})
+		}
+
+		h.log.With(
+			"provider.id", pinfo.Identifier,
+			"playlist.found", pr.Found,
+			"playlist.total", report.Total,
+		).Info("searched for playlist songs")
+		report.Providers = append(report.Providers, pr)
+	}
+
+	return pl, report, nil
+}
+
+// CSV returns the unmatched songs of the report as CSV, one row for each
+// song and provider it couldn't be found on.
+func (r *PlaylistReport) CSV() ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"provider", "title", "artists", "isrc", "url"}); err != nil {
+		return nil, fmt.Errorf("failed to write header: %w", err)
+	}
+	for _, pr := range r.Providers {
+		for _, s := range pr.Unmatched {
+			if err := w.Write([]string{pr.ProviderID, s.Title, strings.Join(s.Artists, ", "), s.ISRC, s.URL}); err != nil {
+				return nil, fmt.Errorf("failed to write row: %w", err)
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return buf.Bytes(), nil
+}
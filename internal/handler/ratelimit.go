@@ -0,0 +1,71 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// rateLimiter limits how often something can be done per key (e.g., a
+// user ID) using a sliding window. It is safe for concurrent use.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	// limit is the number of times something can be done per window.
+	limit  int
+	window time.Duration
+
+	// seen contains the times something was done in the current window,
+	// oldest first, for every key.
+	seen map[string][]time.Time
+}
+
+// newRateLimiter creates a rateLimiter allowing limit events per window
+// for each key.
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, seen: make(map[string][]time.Time)}
+}
+
+// allow records an event for the provided key and returns true, unless
+// the key has reached its limit, in which case false is returned.
+func (r *rateLimiter) allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	// Forget events outside of the window. This also keeps the map from
+	// growing forever.
+	for k, times := range r.seen {
+		times = slices.DeleteFunc(times, func(t time.Time) bool { return now.Sub(t) >= r.window })
+		if len(times) == 0 {
+			delete(r.seen, k)
+			continue
+		}
+		r.seen[k] = times
+	}
+
+	if len(r.seen[key]) >= r.limit {
+		return false
+	}
+
+	r.seen[key] = append(r.seen[key], now)
+	return true
+}
@@ -0,0 +1,289 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jaredallard/miku/internal/settings"
+)
+
+const (
+	// repliesBucket contains the replies sent by miku, keyed by the ID of
+	// the message they were sent in reply to.
+	repliesBucket = "replies"
+
+	// maxInMemoryReplies is the number of replies remembered when
+	// settings aren't persisted.
+	maxInMemoryReplies = 1000
+
+	// maxReplyAge is how long replies are remembered for when settings
+	// are persisted, based on when the original message was sent.
+	maxReplyAge = 30 * 24 * time.Hour
+
+	// replyPruneInterval is how often persisted replies older than
+	// maxReplyAge are forgotten.
+	replyPruneInterval = time.Hour
+)
+
+// reply contains the messages miku sent in reply to a message.
+type reply struct {
+	// ChannelID is the channel the messages were sent in.
+	ChannelID string `json:"channel_id"`
+
+	// MessageIDs contains the IDs of the messages sent, in order.
+	MessageIDs []string `json:"message_ids"`
+
+	// URLs contains the URLs found in the original message, used to
+	// determine if an edit changed them.
+	URLs []string `json:"urls"`
+}
+
+// replies keeps track of the messages miku sent in reply to a message,
+// so that they can be updated when the original message is edited or
+// deleted. Replies are persisted in Config.Settings if it is set (so
+// that they survive restarts) until they're older than maxReplyAge,
+// otherwise the most recent ones are kept in memory. It is safe for
+// concurrent use.
+type replies struct {
+	mu    sync.Mutex
+	store *settings.Bucket
+
+	// lastPrune is when old replies were last removed from store.
+	lastPrune time.Time
+
+	// order contains the keys of byID, oldest first. Only used when store
+	// is nil.
+	order []string
+	byID  map[string]*reply
+}
+
+// newReplies creates a replies from the provided configuration.
+func newReplies(conf *Config) *replies {
+	r := &replies{byID: make(map[string]*reply)}
+	if conf.Settings != nil {
+		r.store = conf.Settings.Bucket(repliesBucket)
+	}
+	return r
+}
+
+// get returns the reply sent to the provided message, or nil if there
+// isn't one.
+func (r *replies) get(messageID string) (*reply, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store == nil {
+		return r.byID[messageID], nil
+	}
+
+	var rep reply
+	if err := r.store.Get(messageID, &rep); err != nil {
+		if errors.Is(err, settings.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read reply: %w", err)
+	}
+	return &rep, nil
+}
+
+// set stores the reply sent to the provided message.
+func (r *replies) set(messageID string, rep *reply) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store != nil {
+		if err := r.store.Set(messageID, rep); err != nil {
+			return err
+		}
+		return r.prune()
+	}
+
+	if _, ok := r.byID[messageID]; !ok {
+		r.order = append(r.order, messageID)
+	}
+	r.byID[messageID] = rep
+
+	if len(r.order) > maxInMemoryReplies {
+		delete(r.byID, r.order[0])
+		r.order = r.order[1:]
+	}
+	return nil
+}
+
+// prune forgets persisted replies to messages older than maxReplyAge,
+// at most once every replyPruneInterval. Messages are keyed by their
+// ID, which contains when they were sent. r.mu must be held.
+func (r *replies) prune() error {
+	if time.Since(r.lastPrune) < replyPruneInterval {
+		return nil
+	}
+	r.lastPrune = time.Now()
+
+	if err := r.store.DeleteFunc(func(messageID string) bool {
+		sent, err := discordgo.SnowflakeTimestamp(messageID)
+		return err != nil || time.Since(sent) > maxReplyAge
+	}); err != nil {
+		return fmt.Errorf("failed to forget old replies: %w", err)
+	}
+	return nil
+}
+
+// delete forgets the reply sent to the provided message.
+func (r *replies) delete(messageID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store != nil {
+		return r.store.Delete(messageID)
+	}
+
+	delete(r.byID, messageID)
+	r.order = slices.DeleteFunc(r.order, func(id string) bool { return id == messageID })
+	return nil
+}
+
+// rememberReply records the messages sent in reply to m.
+func (h *Handler) rememberReply(m *discordgo.Message, urls []string, sent []*discordgo.Message) {
+	rep := &reply{ChannelID: m.ChannelID, URLs: urls}
+	for _, msg := range sent {
+		rep.MessageIDs = append(rep.MessageIDs, msg.ID)
+	}
+
+	if err := h.replies.set(m.ID, rep); err != nil {
+		h.log.With("err", err).Warn("failed to remember reply")
+	}
+}
+
+// MessageUpdateHandler implements a [discordgo.EventHandler] for
+// messages being edited. If miku replied to the message and the links in
+// it changed, the reply is updated in place.
+func (h *Handler) MessageUpdateHandler(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	ctx := context.Background()
+	if m.Author == nil || m.Author.Bot {
+		return // Ignore bots and updates that aren't edits (e.g., embeds).
+	}
+
+	rep, err := h.replies.get(m.ID)
+	if err != nil {
+		h.log.With("err", err).Warn("failed to lookup reply")
+		return
+	}
+	if rep == nil {
+		return // We didn't reply to this message.
+	}
+
+	urls := extractURLs(m.Content)
+	if slices.Equal(urls, rep.URLs) {
+		return // Links didn't change (e.g., only text or embeds did).
+	}
+	h.log.With("message.id", m.ID, "urls", urls).Debug("links in message changed, updating reply")
+
+	gc := h.guilds.get(m.GuildID)
+	var cs []*conversion
+	for _, u := range urls {
+		c, err := h.convertURL(ctx, gc, u, m.Author)
+		if err != nil {
+			h.log.With("err", err, "url", u).Error("failed to handle url")
+			continue
+		}
+		cs = append(cs, c)
+	}
+	if len(cs) == 0 {
+		// Nothing left to show, so the reply isn't needed anymore.
+		h.deleteReply(s, m.ID, rep)
+		return
+	}
+
+	msgs := buildMessages(cs)
+	var ids []string
+	for i, msg := range msgs {
+		if i < len(rep.MessageIDs) {
+			if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+				ID:         rep.MessageIDs[i],
+				Channel:    rep.ChannelID,
+				Embeds:     &msg.Embeds,
+				Components: &msg.Components,
+				Files:      msg.Files,
+				// Replace attachments (e.g., playlist reports) rather than
+				// adding to them.
+				Attachments: &[]*discordgo.MessageAttachment{},
+			}); err != nil {
+				h.log.With("err", err).Error("failed to update reply")
+				return
+			}
+			ids = append(ids, rep.MessageIDs[i])
+			continue
+		}
+
+		sent, err := s.ChannelMessageSendComplex(rep.ChannelID, msg)
+		if err != nil {
+			h.log.With("err", err).Error("failed to send reply")
+			return
+		}
+		ids = append(ids, sent.ID)
+	}
+
+	// Remove messages that are no longer needed.
+	for _, id := range rep.MessageIDs[min(len(msgs), len(rep.MessageIDs)):] {
+		if err := s.ChannelMessageDelete(rep.ChannelID, id); err != nil {
+			h.log.With("err", err).Warn("failed to delete reply")
+		}
+	}
+
+	if err := h.replies.set(m.ID, &reply{ChannelID: rep.ChannelID, MessageIDs: ids, URLs: urls}); err != nil {
+		h.log.With("err", err).Warn("failed to remember reply")
+	}
+}
+
+// MessageDeleteHandler implements a [discordgo.EventHandler] for
+// messages being deleted. If miku replied to the message, the reply is
+// deleted too.
+func (h *Handler) MessageDeleteHandler(s *discordgo.Session, m *discordgo.MessageDelete) {
+	rep, err := h.replies.get(m.ID)
+	if err != nil {
+		h.log.With("err", err).Warn("failed to lookup reply")
+		return
+	}
+	if rep == nil {
+		return // We didn't reply to this message.
+	}
+
+	h.log.With("message.id", m.ID).Debug("original message deleted, deleting reply")
+	h.deleteReply(s, m.ID, rep)
+}
+
+// deleteReply deletes the messages sent in reply to the message with the
+// provided ID.
+func (h *Handler) deleteReply(s *discordgo.Session, messageID string, rep *reply) {
+	for _, id := range rep.MessageIDs {
+		if err := s.ChannelMessageDelete(rep.ChannelID, id); err != nil {
+			h.log.With("err", err).Warn("failed to delete reply")
+		}
+	}
+
+	if err := h.replies.delete(messageID); err != nil {
+		h.log.With("err", err).Warn("failed to forget reply")
+	}
+}
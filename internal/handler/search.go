@@ -0,0 +1,119 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+const (
+	// searchResultsPerProvider is the number of results requested from
+	// each provider when searching by free text.
+	searchResultsPerProvider = 5
+
+	// minSearchScore is the minimum streamingproviders.QueryScore a
+	// search result must have to be considered a match for a query.
+	minSearchScore = 0.5
+)
+
+// ErrNoConfidentMatch is returned when a free text search returned
+// results, but none of them matched the query closely enough.
+var ErrNoConfidentMatch = errors.New("no confident match for query")
+
+// errTextSearchNotSupported is returned when a provider doesn't
+// implement streamingproviders.TextSearchProvider.
+var errTextSearchNotSupported = errors.New("provider does not support text search")
+
+// scoredSong is a song returned by a free text search along with how
+// well it matches the query.
+type scoredSong struct {
+	song  *streamingproviders.Song
+	score float64
+}
+
+// NewSearch searches all enabled providers that support free text
+// search for the provided query and picks the song that best matches it.
+// It then searches all providers (minus the one the song was found on)
+// and returns the alternatives.
+//
+// ErrFailedToFindOriginal is returned if no provider returned any
+// results, and ErrNoConfidentMatch if none of them matched the query
+// well enough.
+//
+//nolint:gocritic // Why: Documented above.
+func (h *Handler) NewSearch(ctx context.Context, query string) (*streamingproviders.Song,
+	*Alternatives[streamingproviders.Song], error) {
+	// Each provider picks its own best result. Earlier results are
+	// preferred on ties since providers return them ordered by relevance.
+	results := searchAlts(ctx, h, "search result", "", func(ctx context.Context,
+		sp streamingproviders.Provider) (*scoredSong, error) {
+		tp, ok := sp.(streamingproviders.TextSearchProvider)
+		if !ok {
+			return nil, errTextSearchNotSupported
+		}
+
+		songs, err := tp.SearchText(ctx, query, searchResultsPerProvider)
+		if err != nil {
+			return nil, err
+		}
+
+		var best *scoredSong
+		for _, song := range songs {
+			score := streamingproviders.QueryScore(query, song)
+			if best == nil || score > best.score {
+				best = &scoredSong{song, score}
+			}
+		}
+		if best == nil {
+			return nil, fmt.Errorf("%w: no songs returned", streamingproviders.ErrNotFound)
+		}
+		return best, nil
+	})
+	if len(results.Found) == 0 {
+		return nil, nil, ErrFailedToFindOriginal
+	}
+
+	// Providers are in the order they are enabled in, so ties go to the
+	// provider enabled first.
+	var best *scoredSong
+	for _, res := range results.Found {
+		if best == nil || res.score > best.score {
+			best = res
+		}
+	}
+
+	slog := h.log.With(
+		"query", query,
+		"song.isrc", best.song.ISRC,
+		"song.provider", best.song.Provider.Identifier,
+		"song.title", best.song.Title,
+		"song.artists", best.song.Artists,
+		"score", best.score,
+	)
+	if best.score < minSearchScore {
+		slog.Info("best search result did not match query closely enough")
+		return nil, nil, ErrNoConfidentMatch
+	}
+	slog.Info("found song by search")
+
+	return best.song, h.findAlts(ctx, best.song), nil
+}
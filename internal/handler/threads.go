@@ -0,0 +1,81 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"github.com/bwmarrin/discordgo"
+)
+
+// channel returns the channel with the provided ID, using the state
+// cache if possible.
+func (h *Handler) channel(s *discordgo.Session, channelID string) (*discordgo.Channel, error) {
+	if s.State != nil {
+		if ch, err := s.State.Channel(channelID); err == nil {
+			return ch, nil
+		}
+	}
+
+	// Threads that were archived when we connected aren't in the state
+	// cache, so fetch them and cache them for next time.
+	ch, err := s.Channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if s.State != nil {
+		if err := s.State.ChannelAdd(ch); err != nil {
+			h.log.With("err", err, "channel.id", channelID).Debug("failed to cache channel")
+		}
+	}
+	return ch, nil
+}
+
+// threadParentID returns the ID of the channel the provided thread (or
+// forum post) was created in. If the channel isn't a thread, or it can't
+// be looked up, false is returned.
+func (h *Handler) threadParentID(s *discordgo.Session, channelID string) (string, bool) {
+	ch, err := h.channel(s, channelID)
+	if err != nil {
+		h.log.With("err", err, "channel.id", channelID).Debug("failed to lookup channel")
+		return "", false
+	}
+
+	if !ch.IsThread() || ch.ParentID == "" {
+		return "", false
+	}
+	return ch.ParentID, true
+}
+
+// watchedChannelMode returns the mode of the provided channel, or false
+// if it isn't being watched. Threads (and forum posts) use the mode of
+// the channel they were created in.
+func (h *Handler) watchedChannelMode(s *discordgo.Session, gc *GuildConfig, m *discordgo.Message) (ChannelMode, bool) {
+	if mode, ok := h.channelMode(gc, m.ChannelID); ok {
+		return mode, true
+	}
+
+	// Threads only exist in guilds.
+	if m.GuildID == "" {
+		return "", false
+	}
+
+	parentID, ok := h.threadParentID(s, m.ChannelID)
+	if !ok {
+		return "", false
+	}
+	return h.channelMode(gc, parentID)
+}
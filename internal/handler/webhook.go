@@ -0,0 +1,212 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// webhookName is the name of the webhooks created by miku.
+	webhookName = "miku"
+
+	// maxAttachmentsSize is the maximum total size, in bytes, of the
+	// attachments that will be re-uploaded when reposting a message.
+	// This is the upload limit for bots.
+	maxAttachmentsSize = 10 * 1024 * 1024
+
+	// attachmentTimeout is the maximum amount of time spent downloading
+	// the attachments of a message.
+	attachmentTimeout = 30 * time.Second
+)
+
+// webhooks caches the webhook miku uses in each channel, so that they
+// don't have to be looked up for every message. It is safe for
+// concurrent use.
+type webhooks struct {
+	mu        sync.Mutex
+	byChannel map[string]*discordgo.Webhook
+}
+
+// channelWebhook returns the webhook owned by miku in the provided
+// channel, creating it if it doesn't exist.
+func (h *Handler) channelWebhook(s *discordgo.Session, channelID string) (*discordgo.Webhook, error) {
+	h.webhooks.mu.Lock()
+	defer h.webhooks.mu.Unlock()
+
+	if wh, ok := h.webhooks.byChannel[channelID]; ok {
+		return wh, nil
+	}
+
+	whs, err := s.ChannelWebhooks(channelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	var wh *discordgo.Webhook
+	for _, candidate := range whs {
+		// Only webhooks we created have a token we can use.
+		if candidate.User != nil && candidate.User.ID == s.State.User.ID && candidate.Token != "" {
+			wh = candidate
+			break
+		}
+	}
+	if wh == nil {
+		h.log.With("channel.id", channelID).Info("creating webhook")
+		wh, err = s.WebhookCreate(channelID, webhookName, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create webhook: %w", err)
+		}
+	}
+
+	h.webhooks.byChannel[channelID] = wh
+	return wh, nil
+}
+
+// forgetWebhook removes the cached webhook for the provided channel,
+// e.g., because it was deleted.
+func (h *Handler) forgetWebhook(channelID string) {
+	h.webhooks.mu.Lock()
+	defer h.webhooks.mu.Unlock()
+
+	delete(h.webhooks.byChannel, channelID)
+}
+
+// repostAsAuthor sends the provided messages through a webhook using the
+// display name and avatar of the author of m, so that it looks like they
+// posted them. content and the attachments of m are sent with the first
+// message. The original message is not deleted. The number of messages
+// that were sent is returned, even if an error occurred.
+func (h *Handler) repostAsAuthor(s *discordgo.Session, m *discordgo.MessageCreate, content string,
+	msgs []*discordgo.MessageSend) (int, error) {
+	// Webhooks can't be created in threads, only in the channel they were
+	// created in.
+	channelID, threadID := m.ChannelID, ""
+	if parentID, ok := h.threadParentID(s, m.ChannelID); ok {
+		channelID, threadID = parentID, m.ChannelID
+	}
+
+	wh, err := h.channelWebhook(s, channelID)
+	if err != nil {
+		return 0, err
+	}
+
+	files, err := downloadAttachments(m.Attachments)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download attachments: %w", err)
+	}
+
+	username, avatarURL := m.Author.DisplayName(), m.Author.AvatarURL("")
+	if m.Member != nil {
+		member := *m.Member
+		member.User = m.Author
+		member.GuildID = m.GuildID
+		username, avatarURL = member.DisplayName(), member.AvatarURL("")
+	}
+
+	for i, msg := range msgs {
+		params := &discordgo.WebhookParams{
+			Username:   username,
+			AvatarURL:  avatarURL,
+			Embeds:     msg.Embeds,
+			Components: msg.Components,
+			Files:      msg.Files,
+			// The original message already notified anyone mentioned in it.
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		}
+		if i == 0 {
+			params.Content = content
+			params.Files = append(params.Files, files...)
+		}
+
+		if _, err := s.WebhookThreadExecute(wh.ID, wh.Token, false, threadID, params); err != nil {
+			// The webhook may have been deleted, so look it up again next
+			// time.
+			h.forgetWebhook(channelID)
+			return i, fmt.Errorf("failed to execute webhook: %w", err)
+		}
+	}
+
+	return len(msgs), nil
+}
+
+// downloadAttachments downloads the provided attachments so that they
+// can be uploaded again.
+func downloadAttachments(atts []*discordgo.MessageAttachment) ([]*discordgo.File, error) {
+	if len(atts) == 0 {
+		return nil, nil
+	}
+
+	var total int
+	for _, att := range atts {
+		total += att.Size
+	}
+	if total > maxAttachmentsSize {
+		return nil, fmt.Errorf("attachments are too large (%d bytes)", total)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), attachmentTimeout)
+	defer cancel()
+
+	files := make([]*discordgo.File, 0, len(atts))
+	for _, att := range atts {
+		f, err := downloadAttachment(ctx, att)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", att.Filename, err)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// downloadAttachment downloads a single attachment.
+func downloadAttachment(ctx context.Context, att *discordgo.MessageAttachment) (*discordgo.File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, att.URL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxAttachmentsSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return &discordgo.File{
+		Name:        att.Filename,
+		ContentType: att.ContentType,
+		Reader:      bytes.NewReader(b),
+	}, nil
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+// Package settings implements a persistent, on-disk store for state
+// that must not expire, such as settings keyed by guild ID.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// guildsBucket contains settings keyed by guild ID.
+const guildsBucket = "guilds"
+
+// ErrNotFound is returned when nothing is stored under a key.
+var ErrNotFound = errors.New("no settings stored")
+
+// Store is a persistent settings store backed by an embedded database.
+// Settings are encoded as JSON. It is safe for concurrent use.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (creating if needed) the settings store at the provided
+// path.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open settings database: %w", err)
+	}
+
+	return &Store{db}, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Get decodes the settings stored for the provided guild into v.
+// ErrNotFound is returned if nothing is stored for the guild.
+func (s *Store) Get(guildID string, v any) error {
+	return s.Bucket(guildsBucket).Get(guildID, v)
+}
+
+// Set stores v as the settings for the provided guild.
+func (s *Store) Set(guildID string, v any) error {
+	return s.Bucket(guildsBucket).Set(guildID, v)
+}
+
+// Delete removes the settings stored for the provided guild, if any.
+func (s *Store) Delete(guildID string) error {
+	return s.Bucket(guildsBucket).Delete(guildID)
+}
+
+// Bucket returns the bucket with the provided name, which is created
+// when something is first stored in it. Values in different buckets
+// never conflict.
+func (s *Store) Bucket(name string) *Bucket {
+	return &Bucket{s.db, name}
+}
+
+// Bucket is a named collection of values in a Store. Values are encoded
+// as JSON. It is safe for concurrent use.
+type Bucket struct {
+	db   *bolt.DB
+	name string
+}
+
+// Get decodes the value stored under the provided key into v.
+// ErrNotFound is returned if nothing is stored under the key.
+func (b *Bucket) Get(key string, v any) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		bk := tx.Bucket([]byte(b.name))
+		if bk == nil {
+			return ErrNotFound
+		}
+
+		raw := bk.Get([]byte(key))
+		if raw == nil {
+			return ErrNotFound
+		}
+
+		return json.Unmarshal(raw, v)
+	})
+}
+
+// Set stores v under the provided key.
+func (b *Bucket) Set(key string, v any) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bk, err := tx.CreateBucketIfNotExists([]byte(b.name))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %w", err)
+		}
+
+		return bk.Put([]byte(key), raw)
+	})
+}
+
+// Delete removes the value stored under the provided key, if any.
+func (b *Bucket) Delete(key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket([]byte(b.name))
+		if bk == nil {
+			return nil
+		}
+
+		return bk.Delete([]byte(key))
+	})
+}
+
+// DeleteFunc removes every value whose key del returns true for.
+func (b *Bucket) DeleteFunc(del func(key string) bool) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket([]byte(b.name))
+		if bk == nil {
+			return nil
+		}
+
+		// Deleting while iterating with a cursor skips keys, so the keys
+		// are collected first.
+		var keys [][]byte
+		if err := bk.ForEach(func(k, _ []byte) error {
+			if del(string(k)) {
+				keys = append(keys, slices.Clone(k))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, k := range keys {
+			if err := bk.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+// Package shortlinks implements expanding short links (e.g.,
+// spotify.link/abc) into the URL they redirect to.
+package shortlinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/cache"
+)
+
+const (
+	// maxHops is the maximum number of redirects followed.
+	maxHops = 5
+
+	// timeout is the maximum amount of time spent resolving a URL.
+	timeout = 5 * time.Second
+
+	// cacheBucket contains resolved URLs keyed by normalized short link.
+	cacheBucket = "short_links"
+)
+
+// Hostnames contains the hostnames of the link shorteners that are
+// resolved. Only URLs on these hostnames are ever requested.
+var Hostnames = []string{
+	"spotify.link",
+	"spotify.app.link",
+	"spoti.fi",
+	"apple.co",
+	"deezer.page.link",
+	"link.deezer.com",
+	"tidal.link",
+	"youtu.be",
+}
+
+// errNoRedirect is returned when a response isn't a redirect.
+var errNoRedirect = errors.New("response is not a redirect")
+
+// Resolver expands short links. It is safe for concurrent use.
+type Resolver struct {
+	client *http.Client
+	cache  *cache.Cache
+	log    *log.Logger
+}
+
+// New creates a Resolver. If c is set, resolved URLs are cached in it.
+func New(c *cache.Cache, logger *log.Logger) *Resolver {
+	return &Resolver{
+		client: &http.Client{
+			// Redirects are followed manually so that we never request a
+			// URL that isn't a short link.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cache: c,
+		log:   logger,
+	}
+}
+
+// IsShortLink returns true if the provided URL is on a known link
+// shortener.
+func IsShortLink(u *url.URL) bool {
+	return slices.Contains(Hostnames, strings.ToLower(u.Hostname()))
+}
+
+// Resolve returns the URL the provided URL redirects to, following up to
+// maxHops redirects between link shorteners. URLs that aren't short
+// links are returned as-is.
+func (r *Resolver) Resolve(ctx context.Context, urlStr string) (string, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse url: %w", err)
+	}
+	if !IsShortLink(u) {
+		return urlStr, nil
+	}
+
+	key := cache.NormalizeURL(u)
+	if r.cache != nil {
+		var resolved string
+		switch err := r.cache.Get(cacheBucket, key, &resolved); {
+		case err == nil:
+			return resolved, nil
+		case !errors.Is(err, cache.ErrMiss):
+			r.log.With("err", err, "cache.key", key).Warn("failed to read from cache")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for range maxHops {
+		next, err := r.next(ctx, u)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", u, err)
+		}
+		r.log.With("from", u.String(), "to", next.String()).Debug("followed short link redirect")
+
+		u = next
+		if IsShortLink(u) {
+			continue
+		}
+
+		resolved := u.String()
+		if r.cache != nil {
+			if err := r.cache.Set(cacheBucket, key, resolved); err != nil {
+				r.log.With("err", err, "cache.key", key).Warn("failed to write to cache")
+			}
+		}
+		return resolved, nil
+	}
+
+	return "", fmt.Errorf("failed to resolve %s: too many redirects", urlStr)
+}
+
+// next returns the URL the provided URL redirects to. A HEAD request is
+// tried first, as it's cheaper, falling back to a GET request as not
+// every shortener supports HEAD.
+func (r *Resolver) next(ctx context.Context, u *url.URL) (*url.URL, error) {
+	next, err := r.redirect(ctx, http.MethodHead, u)
+	if err == nil {
+		return next, nil
+	}
+
+	return r.redirect(ctx, http.MethodGet, u)
+}
+
+// redirect makes a request with the provided method and returns the URL
+// the response redirects to.
+func (r *Resolver) redirect(ctx context.Context, method string, u *url.URL) (*url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain (some of) the body so that the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024)) //nolint:errcheck,gosec // Why: Best effort.
+
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("%w (status code %d)", errNoRedirect, resp.StatusCode)
+	}
+
+	next, err := resp.Location()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redirect location: %w", err)
+	}
+	return next, nil
+}
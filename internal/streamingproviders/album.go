@@ -0,0 +1,50 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"slices"
+	"strings"
+)
+
+// UPCVariants returns the forms the provided UPC may be stored as by a
+// provider, starting with the UPC itself. Providers don't agree on
+// whether an album is identified by its 12 digit UPC-A or by the
+// equivalent 13 digit EAN-13 (the same code with a leading zero), so
+// both are returned. UPCs that aren't numeric are returned as is.
+func UPCVariants(upc string) []string {
+	upc = strings.TrimSpace(upc)
+	variants := []string{upc}
+	if strings.Trim(upc, "0123456789") != "" {
+		return variants
+	}
+
+	trimmed := strings.TrimLeft(upc, "0")
+	if trimmed == "" {
+		return variants
+	}
+	for _, length := range []int{12, 13} {
+		if len(trimmed) > length {
+			continue
+		}
+		if v := strings.Repeat("0", length-len(trimmed)) + trimmed; !slices.Contains(variants, v) {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
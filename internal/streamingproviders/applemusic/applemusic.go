@@ -21,7 +21,9 @@ package applemusic
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
@@ -34,20 +36,62 @@ import (
 
 var _ streamingproviders.Provider = &Provider{}
 
+var _ streamingproviders.AlbumProvider = &Provider{}
+
+var _ streamingproviders.ArtistProvider = &Provider{}
+
+var _ streamingproviders.PlaylistProvider = &Provider{}
+
+var _ streamingproviders.TextSearchProvider = &Provider{}
+
+// variousArtists is the artist Apple Music credits compilations to.
+const variousArtists = "Various Artists"
+
 // Provider implements [streamingproviders.Provider] for Apple Music.
 type Provider struct {
 	client *goapplemusic.Client
+
+	// storefront is the storefront searched in unless one is set through
+	// WithStorefront.
+	storefront string
+
+	// fallbackStorefronts are searched, in order, when something can't
+	// be found in the storefront returned by Storefront.
+	fallbackStorefronts []string
 }
 
 // New returns a new streamingprovider.Provider for Apple Music.
 // Requires the following environment variables:
 // - MIKU_APPLE_MUSIC_API_TOKEN
+//
+// The following environment variables are optional:
+// - MIKU_APPLE_MUSIC_STOREFRONT (defaults to DefaultStorefront)
+// - MIKU_APPLE_MUSIC_FALLBACK_STOREFRONTS (comma separated)
 func New(_ context.Context, _ *log.Logger) (streamingproviders.Provider, error) {
 	token := os.Getenv("MIKU_APPLE_MUSIC_API_TOKEN")
+	if token == "" {
+		return nil, fmt.Errorf("%w: MIKU_APPLE_MUSIC_API_TOKEN must be set", streamingproviders.ErrNotConfigured)
+	}
+
+	storefront := DefaultStorefront
+	if sf := os.Getenv("MIKU_APPLE_MUSIC_STOREFRONT"); sf != "" {
+		storefront = strings.ToLower(sf)
+		if !ValidStorefront(storefront) {
+			return nil, fmt.Errorf("invalid MIKU_APPLE_MUSIC_STOREFRONT %q, expected a two letter country code", sf)
+		}
+	}
+
+	fallbacks, err := parseStorefronts(os.Getenv("MIKU_APPLE_MUSIC_FALLBACK_STOREFRONTS"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MIKU_APPLE_MUSIC_FALLBACK_STOREFRONTS: %w", err)
+	}
 
 	tp := goapplemusic.Transport{Token: token}
-	client := goapplemusic.NewClient(tp.Client())
-	return &Provider{client}, nil
+	return &Provider{
+		client:              goapplemusic.NewClient(tp.Client()),
+		storefront:          storefront,
+		fallbackStorefronts: fallbacks,
+	}, nil
 }
 
 // Info returns information about this provider.
@@ -71,33 +115,30 @@ func (p *Provider) LookupSongByURL(ctx context.Context, u *url.URL) (*streamingp
 		return nil, fmt.Errorf("missing 'i' query parameter")
 	}
 
-	// Storefront is the first part of the URL.
-	storefront := strings.Split(u.Path, "/")[1]
+	// The song is looked up in the storefront it was shared from, as it
+	// may not be available in others.
+	storefront, _ := p.splitPath(ctx, u)
 
 	songs, _, err := p.client.Catalog.GetSong(ctx, storefront, id, &goapplemusic.Options{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get song: %w", err)
 	}
 	if len(songs.Data) == 0 {
-		return nil, fmt.Errorf("no songs returned")
+		return nil, fmt.Errorf("%w: no songs returned", streamingproviders.ErrNotFound)
 	}
 	if len(songs.Data) > 1 {
 		return nil, fmt.Errorf("more than one song returned, not sure how to handle this (yet)")
 	}
 
 	// Use the first song.
-	song := songs.Data[0]
-	return p.musicSongToSong(&song), nil
+	song := p.musicSongToSong(&songs.Data[0])
+	song.AvailableMarkets = []string{storefront}
+	return song, nil
 }
 
 // musicSongToSong converts a goapplemusic.Song to a
 // streamingproviders.Song.
 func (p *Provider) musicSongToSong(song *goapplemusic.Song) *streamingproviders.Song {
-	// Crude attempt at getting a 100x100 image. Not sure why they force
-	// you to set the size...
-	artworkURL := strings.Replace(song.Attributes.Artwork.URL, "{w}", "100", 1)
-	artworkURL = strings.Replace(artworkURL, "{h}", "100", 1)
-
 	return &streamingproviders.Song{
 		Provider:    p.Info(),
 		ProviderURL: song.Attributes.URL,
@@ -106,25 +147,424 @@ func (p *Provider) musicSongToSong(song *goapplemusic.Song) *streamingproviders.
 		Artists:     []string{song.Attributes.ArtistName},
 		Album:       song.Attributes.AlbumName,
 		Duration:    int(song.Attributes.DurationInMillis / 1000),
-		AlbumArtURL: artworkURL,
+		AlbumArtURL: artworkURL(&song.Attributes.Artwork),
+		Explicit:    song.Attributes.ContentRating == "explicit",
 	}
 }
 
+// artworkURL returns a URL for the provided artwork.
+func artworkURL(artwork *goapplemusic.Artwork) string {
+	// Crude attempt at getting a 100x100 image. Not sure why they force
+	// you to set the size...
+	u := strings.Replace(artwork.URL, "{w}", "100", 1)
+	return strings.Replace(u, "{h}", "100", 1)
+}
+
 // Search returns a song from this provider using a Song provided
 // from another provider.
 func (p *Provider) Search(ctx context.Context, song *streamingproviders.Song) (*streamingproviders.Song, error) {
-	// TODO: How do we support other storefronts?
-	songs, _, err := p.client.Catalog.GetSongsByIsrcs(ctx,
-		"us", []string{song.ISRC}, &goapplemusic.Options{},
-	)
+	if song.ISRC == "" {
+		return p.fuzzySearch(ctx, song)
+	}
+
+	// Songs aren't available in every storefront, so each one is
+	// searched in order until the song is found.
+	for _, sf := range p.storefronts(ctx) {
+		// Albums are included to tell apart releases of the song.
+		songs, _, err := p.client.Catalog.GetSongsByIsrcs(ctx,
+			sf, []string{song.ISRC}, &goapplemusic.Options{Include: "albums"},
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get song: %w", err)
+		}
+		if len(songs.Data) == 0 {
+			continue
+		}
+
+		alt := streamingproviders.BestISRCCandidate(song, p.isrcCandidates(songs.Data))
+		alt.AvailableMarkets = []string{sf}
+		return alt, nil
+	}
+
+	// The ISRC may differ between distributors.
+	return p.fuzzySearch(ctx, song)
+}
+
+// isrcCandidates converts the songs returned when searching for an ISRC
+// into candidates for streamingproviders.BestISRCCandidate.
+func (p *Provider) isrcCandidates(songs []goapplemusic.Song) []streamingproviders.ISRCCandidate {
+	candidates := make([]streamingproviders.ISRCCandidate, 0, len(songs))
+	for i := range songs {
+		s := &songs[i]
+
+		// Apple Music doesn't expose popularity, and goapplemusic doesn't
+		// decode whether an album is a compilation, so rely on the
+		// artist compilations are credited to.
+		var compilation bool
+		if albums := s.Relationships.Albums.Data; len(albums) > 0 {
+			compilation = albums[0].Attributes.ArtistName == variousArtists
+		}
+
+		candidates = append(candidates, streamingproviders.ISRCCandidate{
+			Song:        p.musicSongToSong(s),
+			ReleaseDate: s.Attributes.ReleaseDate,
+			Compilation: compilation,
+		})
+	}
+	return candidates
+}
+
+// album is a goapplemusic.Album that also contains the UPC of the
+// album, which isn't exposed by goapplemusic.
+type album struct {
+	goapplemusic.Album
+
+	Attributes struct {
+		goapplemusic.AlbumAttributes
+
+		UPC string `json:"upc"`
+	} `json:"attributes"`
+}
+
+// getAlbums fetches albums from the provided catalog API path. This is
+// used instead of the goapplemusic helpers so that we can decode the
+// UPC of the albums.
+func (p *Provider) getAlbums(ctx context.Context, u string) ([]album, error) {
+	req, err := p.client.NewRequest(http.MethodGet, u, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get song: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	if len(songs.Data) == 0 {
-		return nil, fmt.Errorf("no songs returned")
+
+	var albums struct {
+		Data []album `json:"data"`
+	}
+	if _, err := p.client.Do(ctx, req, &albums); err != nil {
+		return nil, err
 	}
+	return albums.Data, nil
+}
 
-	// Use the first song.
-	alt := songs.Data[0]
-	return p.musicSongToSong(&alt), nil
+// albumToAlbum converts an album to a streamingproviders.Album.
+func (p *Provider) albumToAlbum(a *album) *streamingproviders.Album {
+	return &streamingproviders.Album{
+		Provider:    p.Info(),
+		ProviderURL: a.Attributes.URL,
+		UPC:         a.Attributes.UPC,
+		Title:       a.Attributes.Name,
+		Artists:     []string{a.Attributes.ArtistName},
+		ArtworkURL:  artworkURL(&a.Attributes.Artwork),
+		ReleaseDate: a.Attributes.ReleaseDate,
+		TrackCount:  int(a.Attributes.TrackCount),
+	}
+}
+
+// LookupAlbumByURL returns an album from the provided URL. URL format
+// should be:
+// https://music.apple.com/us/album/album-name/123456789
+func (p *Provider) LookupAlbumByURL(ctx context.Context, u *url.URL) (*streamingproviders.Album, error) {
+	// Links to a song on an album are handled by LookupSongByURL.
+	if u.Query().Has("i") {
+		return nil, fmt.Errorf("URL is for a song, not an album")
+	}
+
+	// Format: [/<storefront>]/album/[<name>/]<id>
+	storefront, parts := p.splitPath(ctx, u)
+	if len(parts) < 2 || parts[0] != "album" {
+		return nil, fmt.Errorf("invalid path: %s", u.Path)
+	}
+	id := parts[len(parts)-1]
+
+	albums, err := p.getAlbums(ctx, fmt.Sprintf("v1/catalog/%s/albums/%s", storefront, url.PathEscape(id)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get album: %w", err)
+	}
+	if len(albums) == 0 {
+		return nil, fmt.Errorf("%w: no albums returned", streamingproviders.ErrNotFound)
+	}
+
+	return p.albumToAlbum(&albums[0]), nil
+}
+
+// SearchAlbum returns an album from this provider using an Album
+// provided from another provider.
+func (p *Provider) SearchAlbum(ctx context.Context, a *streamingproviders.Album) (*streamingproviders.Album, error) {
+	if a.UPC == "" {
+		return nil, fmt.Errorf("album has no UPC")
+	}
+
+	// Every form of the UPC is searched for at once, as the filter
+	// accepts a comma separated list.
+	upcs := strings.Join(streamingproviders.UPCVariants(a.UPC), ",")
+
+	// Albums aren't available in every storefront, so each one is
+	// searched in order until the album is found.
+	for _, sf := range p.storefronts(ctx) {
+		albums, err := p.getAlbums(ctx, fmt.Sprintf("v1/catalog/%s/albums?filter[upc]=%s", sf, url.QueryEscape(upcs)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get album: %w", err)
+		}
+		if len(albums) > 0 {
+			return p.albumToAlbum(&albums[0]), nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no albums returned", streamingproviders.ErrNotFound)
+}
+
+// artist is a goapplemusic.Artist that also contains the artwork of the
+// artist, which isn't exposed by goapplemusic.
+type artist struct {
+	goapplemusic.Artist
+
+	Attributes struct {
+		goapplemusic.ArtistAttributes
+
+		Artwork goapplemusic.Artwork `json:"artwork"`
+	} `json:"attributes"`
+}
+
+// lookupArtist returns the artist with the provided ID, including their
+// top tracks.
+func (p *Provider) lookupArtist(ctx context.Context, storefront, id string) (*streamingproviders.Artist, error) {
+	req, err := p.client.NewRequest(http.MethodGet,
+		fmt.Sprintf("v1/catalog/%s/artists/%s", storefront, url.PathEscape(id)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var artists struct {
+		Data []artist `json:"data"`
+	}
+	if _, err := p.client.Do(ctx, req, &artists); err != nil {
+		return nil, fmt.Errorf("failed to get artist: %w", err)
+	}
+	if len(artists.Data) == 0 {
+		return nil, fmt.Errorf("%w: no artists returned", streamingproviders.ErrNotFound)
+	}
+	a := &artists.Data[0]
+
+	req, err = p.client.NewRequest(http.MethodGet,
+		fmt.Sprintf("v1/catalog/%s/artists/%s/view/top-songs", storefront, url.PathEscape(id)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	var songs goapplemusic.Songs
+	if _, err := p.client.Do(ctx, req, &songs); err != nil {
+		return nil, fmt.Errorf("failed to get top songs: %w", err)
+	}
+
+	isrcs := make([]string, 0, len(songs.Data))
+	for i := range songs.Data {
+		if isrc := songs.Data[i].Attributes.ISRC; isrc != "" {
+			isrcs = append(isrcs, isrc)
+		}
+	}
+
+	return &streamingproviders.Artist{
+		Provider:      p.Info(),
+		ProviderURL:   a.Attributes.URL,
+		Name:          a.Attributes.Name,
+		ImageURL:      artworkURL(&a.Attributes.Artwork),
+		TopTrackISRCs: isrcs,
+	}, nil
+}
+
+// LookupArtistByURL returns an artist from the provided URL. URL format
+// should be:
+// https://music.apple.com/us/artist/artist-name/123456789
+func (p *Provider) LookupArtistByURL(ctx context.Context, u *url.URL) (*streamingproviders.Artist, error) {
+	// Format: [/<storefront>]/artist/[<name>/]<id>
+	storefront, parts := p.splitPath(ctx, u)
+	if len(parts) < 2 || parts[0] != "artist" {
+		return nil, fmt.Errorf("invalid path: %s", u.Path)
+	}
+
+	return p.lookupArtist(ctx, storefront, parts[len(parts)-1])
+}
+
+// SearchArtist returns an artist from this provider using an Artist
+// provided from another provider. Artists aren't available in every
+// storefront, so each one is searched in order until the artist is
+// found.
+func (p *Provider) SearchArtist(ctx context.Context, a *streamingproviders.Artist) (*streamingproviders.Artist, error) {
+	var err error
+	for _, sf := range p.storefronts(ctx) {
+		var found *streamingproviders.Artist
+		found, err = p.searchArtist(ctx, sf, a)
+		if err == nil {
+			return found, nil
+		}
+		if !errors.Is(err, streamingproviders.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// searchArtist searches the provided storefront for an artist provided
+// from another provider.
+func (p *Provider) searchArtist(ctx context.Context, sf string, a *streamingproviders.Artist) (*streamingproviders.Artist, error) {
+	res, _, err := p.client.Catalog.Search(ctx, sf, &goapplemusic.SearchOptions{
+		Term:  a.Name,
+		Types: "artists",
+		Limit: 5,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for artist: %w", err)
+	}
+	if res.Results.Artists == nil || len(res.Results.Artists.Data) == 0 {
+		return nil, fmt.Errorf("%w: no artists returned", streamingproviders.ErrNotFound)
+	}
+
+	candidates := make([]*streamingproviders.Artist, 0, len(res.Results.Artists.Data))
+	var lookupErr error
+	for i := range res.Results.Artists.Data {
+		found := &res.Results.Artists.Data[i]
+
+		// Avoid looking up top tracks for artists that can't match.
+		if !streamingproviders.SameArtistName(found.Attributes.Name, a.Name) {
+			continue
+		}
+
+		// Skip artists that fail to be looked up instead of failing the
+		// search, as another candidate may still match.
+		candidate, err := p.lookupArtist(ctx, sf, found.Id)
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 && lookupErr != nil {
+		return nil, lookupErr
+	}
+
+	return streamingproviders.BestArtistMatch(a, candidates)
+}
+
+// LookupPlaylistByURL returns a playlist from the provided URL. Only
+// catalog (public) playlists are supported. URL format should be:
+// https://music.apple.com/us/playlist/playlist-name/pl.123456789
+func (p *Provider) LookupPlaylistByURL(ctx context.Context, u *url.URL, maxSongs int) (*streamingproviders.Playlist, error) {
+	// Format: [/<storefront>]/playlist/[<name>/]<id>
+	storefront, parts := p.splitPath(ctx, u)
+	if len(parts) < 2 || parts[0] != "playlist" {
+		return nil, fmt.Errorf("invalid path: %s", u.Path)
+	}
+	id := parts[len(parts)-1]
+
+	playlists, _, err := p.client.Catalog.GetPlaylist(ctx, storefront, id, &goapplemusic.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist: %w", err)
+	}
+	if len(playlists.Data) == 0 {
+		return nil, fmt.Errorf("%w: no playlists returned", streamingproviders.ErrNotFound)
+	}
+	playlist := &playlists.Data[0]
+
+	pl := &streamingproviders.Playlist{
+		Provider:    p.Info(),
+		ProviderURL: playlist.Attributes.URL,
+		Name:        playlist.Attributes.Name,
+		Owner:       playlist.Attributes.CuratorName,
+	}
+	if playlist.Attributes.Artwork != nil {
+		pl.ImageURL = artworkURL(playlist.Attributes.Artwork)
+	}
+
+	// Playlist tracks are paginated, so fetch them separately rather than
+	// relying on the ones included with the playlist.
+	next := fmt.Sprintf("v1/catalog/%s/playlists/%s/tracks?limit=100", storefront, url.PathEscape(id))
+	for next != "" && len(pl.Songs) < maxSongs {
+		req, err := p.client.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		var songs goapplemusic.Songs
+		if _, err := p.client.Do(ctx, req, &songs); err != nil {
+			return nil, fmt.Errorf("failed to get playlist tracks: %w", err)
+		}
+
+		for i := range songs.Data {
+			// Music videos can be included in playlists, skip them.
+			if songs.Data[i].Type != "songs" || len(pl.Songs) >= maxSongs {
+				continue
+			}
+			pl.Songs = append(pl.Songs, p.musicSongToSong(&songs.Data[i]))
+		}
+		next = songs.Next
+	}
+
+	return pl, nil
+}
+
+// SearchText returns songs from this provider matching the provided
+// free text query. Songs aren't available in every storefront, so each
+// one is searched in order until songs are found.
+func (p *Provider) SearchText(ctx context.Context, query string, limit int) ([]*streamingproviders.Song, error) {
+	for _, sf := range p.storefronts(ctx) {
+		songs, err := p.searchText(ctx, sf, query, limit)
+		if errors.Is(err, streamingproviders.ErrNotFound) {
+			continue
+		}
+		return songs, err
+	}
+	return nil, fmt.Errorf("%w: no songs returned", streamingproviders.ErrNotFound)
+}
+
+// searchText returns songs from the provided storefront matching the
+// provided free text query.
+func (p *Provider) searchText(ctx context.Context, sf, query string, limit int) ([]*streamingproviders.Song, error) {
+	res, _, err := p.client.Catalog.Search(ctx, sf, &goapplemusic.SearchOptions{
+		Term:  query,
+		Types: "songs",
+		Limit: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for songs: %w", err)
+	}
+	if res.Results.Songs == nil || len(res.Results.Songs.Data) == 0 {
+		return nil, fmt.Errorf("%w: no songs returned", streamingproviders.ErrNotFound)
+	}
+
+	songs := make([]*streamingproviders.Song, 0, len(res.Results.Songs.Data))
+	for i := range res.Results.Songs.Data {
+		song := p.musicSongToSong(&res.Results.Songs.Data[i])
+		song.AvailableMarkets = []string{sf}
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
+
+// storefrontSearcher is a streamingproviders.TextSearchProvider that
+// only searches a single storefront.
+type storefrontSearcher struct {
+	p          *Provider
+	storefront string
+}
+
+// SearchText implements streamingproviders.TextSearchProvider.
+func (s storefrontSearcher) SearchText(ctx context.Context, query string, limit int) ([]*streamingproviders.Song, error) {
+	return s.p.searchText(ctx, s.storefront, query, limit)
+}
+
+// fuzzySearch calls streamingproviders.FuzzySearch on each storefront in
+// order until the song is found. Each storefront is matched on its own,
+// as the results of the first storefront may contain songs that are
+// close, but not close enough, to the song.
+func (p *Provider) fuzzySearch(ctx context.Context, song *streamingproviders.Song) (*streamingproviders.Song, error) {
+	var err error
+	for _, sf := range p.storefronts(ctx) {
+		var alt *streamingproviders.Song
+		alt, err = streamingproviders.FuzzySearch(ctx, storefrontSearcher{p, sf}, song)
+		if err == nil {
+			return alt, nil
+		}
+		if !errors.Is(err, streamingproviders.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, err
 }
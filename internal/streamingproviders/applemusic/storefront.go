@@ -0,0 +1,106 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package applemusic
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultStorefront is the storefront used when searching if one isn't
+// set through WithStorefront or MIKU_APPLE_MUSIC_STOREFRONT.
+const DefaultStorefront = "us"
+
+// storefrontRegexp matches a valid storefront (ISO 3166-1 alpha-2 country
+// code, lowercased).
+var storefrontRegexp = regexp.MustCompile(`^[a-z]{2}$`)
+
+// ValidStorefront returns true if the provided string is formatted like
+// an Apple Music storefront (e.g., "us").
+func ValidStorefront(sf string) bool {
+	return storefrontRegexp.MatchString(sf)
+}
+
+// parseStorefronts parses a comma separated list of storefronts (e.g.,
+// "gb, jp"). Duplicates are removed.
+func parseStorefronts(s string) ([]string, error) {
+	var sfs []string
+	for _, sf := range strings.Split(s, ",") {
+		sf = strings.ToLower(strings.TrimSpace(sf))
+		if sf == "" {
+			continue
+		}
+		if !ValidStorefront(sf) {
+			return nil, fmt.Errorf("invalid storefront %q, expected a two letter country code", sf)
+		}
+		if !slices.Contains(sfs, sf) {
+			sfs = append(sfs, sf)
+		}
+	}
+	return sfs, nil
+}
+
+// storefrontKey is the context key used to store the storefront.
+type storefrontKey struct{}
+
+// WithStorefront returns a context that makes the provider search the
+// provided storefront instead of its default storefront.
+func WithStorefront(ctx context.Context, sf string) context.Context {
+	return context.WithValue(ctx, storefrontKey{}, sf)
+}
+
+// Storefront returns the storefront the provider searches in first when
+// called with the provided context.
+func (p *Provider) Storefront(ctx context.Context) string {
+	if sf, ok := ctx.Value(storefrontKey{}).(string); ok && sf != "" {
+		return sf
+	}
+	return p.storefront
+}
+
+// storefronts returns the storefronts the provider searches in, in
+// order, when called with the provided context. Songs aren't available
+// in every storefront, so the fallback storefronts are searched if a
+// song can't be found in the storefront returned by Storefront.
+func (p *Provider) storefronts(ctx context.Context) []string {
+	sfs := []string{p.Storefront(ctx)}
+	for _, sf := range p.fallbackStorefronts {
+		if !slices.Contains(sfs, sf) {
+			sfs = append(sfs, sf)
+		}
+	}
+	return sfs
+}
+
+// splitPath returns the storefront an Apple Music URL links to, along
+// with the rest of its path split into segments. For example,
+// https://music.apple.com/gb/album/name/123 returns "gb" and
+// ["album", "name", "123"]. URLs without a storefront (e.g.,
+// https://music.apple.com/album/name/123) use the storefront returned by
+// Storefront.
+func (p *Provider) splitPath(ctx context.Context, u *url.URL) (string, []string) {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) > 0 && ValidStorefront(parts[0]) {
+		return parts[0], parts[1:]
+	}
+	return p.Storefront(ctx), parts
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SameArtistName returns true if the two artist names should be
+// considered the same.
+func SameArtistName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+}
+
+// BestArtistMatch returns the candidate that is most likely to be the
+// same artist as the provided artist. Candidates must have the same name
+// (case-insensitive) as the artist. If both the artist and a candidate
+// have top tracks, they must share at least one ISRC to be considered a
+// match, with the candidate sharing the most being returned. Candidates
+// without top tracks can't be cross-checked, so the first of them is
+// only returned if no candidate shares an ISRC with the artist.
+//
+// If the artist has no top tracks, the first candidate with the same
+// name is returned. If no candidate matches, an error wrapping
+// ErrNotFound is returned.
+func BestArtistMatch(artist *Artist, candidates []*Artist) (*Artist, error) {
+	isrcs := make(map[string]struct{}, len(artist.TopTrackISRCs))
+	for _, isrc := range artist.TopTrackISRCs {
+		isrcs[isrc] = struct{}{}
+	}
+
+	var best, fallback *Artist
+	var bestShared int
+	for _, c := range candidates {
+		if !SameArtistName(c.Name, artist.Name) {
+			continue
+		}
+
+		// Nothing to cross-check with, trust the provider's ordering.
+		if len(isrcs) == 0 {
+			return c, nil
+		}
+		if len(c.TopTrackISRCs) == 0 {
+			if fallback == nil {
+				fallback = c
+			}
+			continue
+		}
+
+		var shared int
+		for _, isrc := range c.TopTrackISRCs {
+			if _, ok := isrcs[isrc]; ok {
+				shared++
+			}
+		}
+
+		if shared > bestShared {
+			best, bestShared = c, shared
+		}
+	}
+	if best == nil {
+		best = fallback
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: no matching artist named %q found", ErrNotFound, artist.Name)
+	}
+
+	return best, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBestArtistMatch(t *testing.T) {
+	nirvana := &Artist{Name: "Nirvana", TopTrackISRCs: []string{"USGF19942501", "USGF19942502", "USGF19942503"}}
+	sixties := &Artist{Name: "Nirvana", TopTrackISRCs: []string{"GBAAA6800001"}}
+	grunge := &Artist{Name: "nirvana", TopTrackISRCs: []string{"USGF19942501", "USGF19942503"}}
+	partial := &Artist{Name: "Nirvana", TopTrackISRCs: []string{"USGF19942502"}}
+	noTracks := &Artist{Name: "Nirvana"}
+	other := &Artist{Name: "Nirvana Tribute Band", TopTrackISRCs: []string{"USGF19942501"}}
+
+	tests := []struct {
+		name       string
+		artist     *Artist
+		candidates []*Artist
+		want       *Artist
+		wantErr    bool
+	}{
+		{
+			name:       "most shared top tracks",
+			artist:     nirvana,
+			candidates: []*Artist{sixties, partial, grunge},
+			want:       grunge,
+		},
+		{
+			name:       "different name",
+			artist:     nirvana,
+			candidates: []*Artist{other, partial},
+			want:       partial,
+		},
+		{
+			name:       "no top tracks to compare",
+			artist:     &Artist{Name: "Nirvana"},
+			candidates: []*Artist{other, sixties, grunge},
+			want:       sixties,
+		},
+		{
+			name:       "candidate without top tracks is a fallback",
+			artist:     nirvana,
+			candidates: []*Artist{noTracks, sixties},
+			want:       noTracks,
+		},
+		{
+			name:       "shared top tracks over fallback",
+			artist:     nirvana,
+			candidates: []*Artist{noTracks, partial},
+			want:       partial,
+		},
+		{
+			name:       "no shared top tracks",
+			artist:     nirvana,
+			candidates: []*Artist{sixties},
+			wantErr:    true,
+		},
+		{
+			name:    "no candidates",
+			artist:  nirvana,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BestArtistMatch(tt.artist, tt.candidates)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("BestArtistMatch() error = %v, want %v", err, ErrNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BestArtistMatch() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BestArtistMatch() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+// Package deezer implements a streamingproviders.Provider for the
+// Deezer streaming service.
+package deezer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+const (
+	// apiBaseURL is the base URL of the Deezer API.
+	apiBaseURL = "https://api.deezer.com"
+
+	// topTracksLimit is the number of top tracks fetched for an artist.
+	// Deezer doesn't return ISRCs in track listings, so each top track
+	// requires its own request.
+	topTracksLimit = 3
+
+	// dataNotFoundCode is the error code returned by the Deezer API when
+	// the requested resource doesn't exist.
+	dataNotFoundCode = 800
+)
+
+// _ ensures that Provider implements the streamingproviders.Provider
+// interface.
+var _ streamingproviders.Provider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.AlbumProvider interface.
+var _ streamingproviders.AlbumProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.ArtistProvider interface.
+var _ streamingproviders.ArtistProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.TextSearchProvider interface.
+var _ streamingproviders.TextSearchProvider = &Provider{}
+
+// Provider implements a streamingproviders.Provider for Deezer.
+type Provider struct {
+	client *http.Client
+}
+
+// New returns a new Deezer client. Deezer's API doesn't require
+// authentication for public catalog lookups, so this provider is always
+// available.
+func New(_ context.Context, _ *log.Logger) (streamingproviders.Provider, error) {
+	return &Provider{http.DefaultClient}, nil
+}
+
+// Info returns information about this provider.
+func (p *Provider) Info() streamingproviders.Info {
+	return streamingproviders.Info{
+		Identifier: "deezer",
+		Name:       "Deezer",
+		Emoji: discordgo.ComponentEmoji{
+			Name: "🎧",
+		},
+		URLHostname:            "www.deezer.com",
+		AdditionalURLHostnames: []string{"deezer.com"},
+	}
+}
+
+// apiError is an error returned by the Deezer API. Deezer returns these
+// with a 200 status code, so they need to be checked for explicitly.
+type apiError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+// Error implements the error interface.
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Type, e.Code, e.Message)
+}
+
+// Unwrap returns streamingproviders.ErrNotFound if the error denotes
+// that the requested resource doesn't exist.
+func (e *apiError) Unwrap() error {
+	if e.Code == dataNotFoundCode {
+		return streamingproviders.ErrNotFound
+	}
+	return nil
+}
+
+// track is a track returned by the Deezer API. Only the fields used by
+// this package are decoded.
+type track struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	ISRC     string `json:"isrc"`
+	Link     string `json:"link"`
+	Duration int    `json:"duration"`
+	Explicit bool   `json:"explicit_lyrics"`
+
+	Artist struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+
+	Contributors []struct {
+		Name string `json:"name"`
+	} `json:"contributors"`
+
+	Album struct {
+		Title       string `json:"title"`
+		CoverMedium string `json:"cover_medium"`
+	} `json:"album"`
+}
+
+// album is an album returned by the Deezer API. Only the fields used by
+// this package are decoded.
+type album struct {
+	ID          int64  `json:"id"`
+	Title       string `json:"title"`
+	UPC         string `json:"upc"`
+	Link        string `json:"link"`
+	CoverMedium string `json:"cover_medium"`
+	NbTracks    int    `json:"nb_tracks"`
+	ReleaseDate string `json:"release_date"`
+
+	Artist struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+
+	Contributors []struct {
+		Name string `json:"name"`
+	} `json:"contributors"`
+}
+
+// get performs a GET request against the Deezer API and decodes the
+// response into v.
+func (p *Provider) get(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBaseURL+endpoint, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var errResp struct {
+		Error *apiError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		return errResp.Error
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// artist is an artist returned by the Deezer API. Only the fields used
+// by this package are decoded.
+type artist struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	Link          string `json:"link"`
+	PictureMedium string `json:"picture_medium"`
+}
+
+// getTrack returns a track from the Deezer API. The ID may either be a
+// numeric track ID or an ISRC in the form "isrc:<ISRC>".
+func (p *Provider) getTrack(ctx context.Context, id string) (*track, error) {
+	var t track
+	if err := p.get(ctx, "/track/"+url.PathEscape(id), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// songFromTrack converts a Deezer track into a streamingproviders.Song.
+func (p *Provider) songFromTrack(t *track) *streamingproviders.Song {
+	artists := make([]string, 0, len(t.Contributors))
+	for _, c := range t.Contributors {
+		artists = append(artists, c.Name)
+	}
+	if len(artists) == 0 && t.Artist.Name != "" {
+		artists = append(artists, t.Artist.Name)
+	}
+
+	return &streamingproviders.Song{
+		Provider:    p.Info(),
+		ProviderURL: t.Link,
+		ISRC:        t.ISRC,
+		Title:       t.Title,
+		Artists:     artists,
+		Album:       t.Album.Title,
+		AlbumArtURL: t.Album.CoverMedium,
+		Duration:    t.Duration,
+		Explicit:    t.Explicit,
+	}
+}
+
+// idFromURL returns the ID of the resource of the provided kind (e.g.,
+// track) from a Deezer URL. Short links (e.g., deezer.page.link) are
+// expanded by the handler before they reach the provider.
+func idFromURL(u *url.URL, kind string) (string, error) {
+	// The path may be prefixed with a locale (e.g., /us/track/123).
+	kindPath, id := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if !strings.HasSuffix(kindPath, "/"+kind+"/") {
+		return "", fmt.Errorf("invalid path: %s", kindPath)
+	}
+
+	return id, nil
+}
+
+// LookupSongByURL returns a song from the provided URL. The URL must
+// match one of the following formats:
+// - https://www.deezer.com/track/3135556
+// - https://www.deezer.com/us/track/3135556
+func (p *Provider) LookupSongByURL(ctx context.Context, u *url.URL) (*streamingproviders.Song, error) {
+	id, err := idFromURL(u, "track")
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := p.getTrack(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find track with ID %s: %w", id, err)
+	}
+
+	return p.songFromTrack(t), nil
+}
+
+// Search returns a song from this provider using a Song provided from
+// another provider.
+func (p *Provider) Search(ctx context.Context, song *streamingproviders.Song) (*streamingproviders.Song, error) {
+	if song.ISRC == "" {
+		return streamingproviders.FuzzySearch(ctx, p, song)
+	}
+
+	t, err := p.getTrack(ctx, "isrc:"+song.ISRC)
+	if errors.Is(err, streamingproviders.ErrNotFound) {
+		// The ISRC may differ between distributors.
+		return streamingproviders.FuzzySearch(ctx, p, song)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for song: %w", err)
+	}
+
+	// Deezer only ever returns a single track for an ISRC.
+	alt := p.songFromTrack(t)
+	alt.MatchMethod = streamingproviders.MatchISRC
+	return alt, nil
+}
+
+// SearchText returns songs from this provider matching the provided
+// free text query.
+func (p *Provider) SearchText(ctx context.Context, query string, limit int) ([]*streamingproviders.Song, error) {
+	var res struct {
+		Data []track `json:"data"`
+	}
+	endpoint := fmt.Sprintf("/search/track?limit=%d&q=%s", limit, url.QueryEscape(query))
+	if err := p.get(ctx, endpoint, &res); err != nil {
+		return nil, fmt.Errorf("failed to search for songs: %w", err)
+	}
+	if len(res.Data) == 0 {
+		return nil, fmt.Errorf("%w: no tracks returned", streamingproviders.ErrNotFound)
+	}
+
+	songs := make([]*streamingproviders.Song, 0, len(res.Data))
+	for i := range res.Data {
+		songs = append(songs, p.songFromTrack(&res.Data[i]))
+	}
+	return songs, nil
+}
+
+// albumFromAlbum converts a Deezer album into a
+// streamingproviders.Album.
+func (p *Provider) albumFromAlbum(a *album) *streamingproviders.Album {
+	artists := make([]string, 0, len(a.Contributors))
+	for _, c := range a.Contributors {
+		artists = append(artists, c.Name)
+	}
+	if len(artists) == 0 && a.Artist.Name != "" {
+		artists = append(artists, a.Artist.Name)
+	}
+
+	return &streamingproviders.Album{
+		Provider:    p.Info(),
+		ProviderURL: a.Link,
+		UPC:         a.UPC,
+		Title:       a.Title,
+		Artists:     artists,
+		ArtworkURL:  a.CoverMedium,
+		ReleaseDate: a.ReleaseDate,
+		TrackCount:  a.NbTracks,
+	}
+}
+
+// LookupAlbumByURL returns an album from the provided URL. The URL must
+// match one of the following formats:
+// - https://www.deezer.com/album/302127
+// - https://www.deezer.com/us/album/302127
+func (p *Provider) LookupAlbumByURL(ctx context.Context, u *url.URL) (*streamingproviders.Album, error) {
+	id, err := idFromURL(u, "album")
+	if err != nil {
+		return nil, err
+	}
+
+	var a album
+	if err := p.get(ctx, "/album/"+url.PathEscape(id), &a); err != nil {
+		return nil, fmt.Errorf("failed to find album with ID %s: %w", id, err)
+	}
+
+	return p.albumFromAlbum(&a), nil
+}
+
+// SearchAlbum returns an album from this provider using an Album
+// provided from another provider.
+func (p *Provider) SearchAlbum(ctx context.Context, a *streamingproviders.Album) (*streamingproviders.Album, error) {
+	if a.UPC == "" {
+		return nil, fmt.Errorf("album has no UPC")
+	}
+
+	var err error
+	for _, upc := range streamingproviders.UPCVariants(a.UPC) {
+		var found album
+		err = p.get(ctx, "/album/upc:"+url.PathEscape(upc), &found)
+		if err == nil {
+			return p.albumFromAlbum(&found), nil
+		}
+		if !errors.Is(err, streamingproviders.ErrNotFound) {
+			break
+		}
+	}
+
+	return nil, fmt.Errorf("failed to search for album: %w", err)
+}
+
+// artistFromArtist converts a Deezer artist into a
+// streamingproviders.Artist, looking up the artist's top tracks.
+func (p *Provider) artistFromArtist(ctx context.Context, a *artist) (*streamingproviders.Artist, error) {
+	var top struct {
+		Data []track `json:"data"`
+	}
+	if err := p.get(ctx, fmt.Sprintf("/artist/%d/top?limit=%d", a.ID, topTracksLimit), &top); err != nil {
+		return nil, fmt.Errorf("failed to get top tracks: %w", err)
+	}
+
+	isrcs := make([]string, 0, len(top.Data))
+	for i := range top.Data {
+		t, err := p.getTrack(ctx, strconv.FormatInt(top.Data[i].ID, 10))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get top track: %w", err)
+		}
+		if t.ISRC != "" {
+			isrcs = append(isrcs, t.ISRC)
+		}
+	}
+
+	return &streamingproviders.Artist{
+		Provider:      p.Info(),
+		ProviderURL:   a.Link,
+		Name:          a.Name,
+		ImageURL:      a.PictureMedium,
+		TopTrackISRCs: isrcs,
+	}, nil
+}
+
+// LookupArtistByURL returns an artist from the provided URL. The URL
+// must match one of the following formats:
+// - https://www.deezer.com/artist/27
+// - https://www.deezer.com/us/artist/27
+func (p *Provider) LookupArtistByURL(ctx context.Context, u *url.URL) (*streamingproviders.Artist, error) {
+	id, err := idFromURL(u, "artist")
+	if err != nil {
+		return nil, err
+	}
+
+	var a artist
+	if err := p.get(ctx, "/artist/"+url.PathEscape(id), &a); err != nil {
+		return nil, fmt.Errorf("failed to find artist with ID %s: %w", id, err)
+	}
+
+	return p.artistFromArtist(ctx, &a)
+}
+
+// SearchArtist returns an artist from this provider using an Artist
+// provided from another provider.
+func (p *Provider) SearchArtist(ctx context.Context, a *streamingproviders.Artist) (*streamingproviders.Artist, error) {
+	var res struct {
+		Data []artist `json:"data"`
+	}
+	if err := p.get(ctx, "/search/artist?limit=5&q="+url.QueryEscape(a.Name), &res); err != nil {
+		return nil, fmt.Errorf("failed to search for artist: %w", err)
+	}
+	if len(res.Data) == 0 {
+		return nil, fmt.Errorf("%w: no artists returned", streamingproviders.ErrNotFound)
+	}
+
+	candidates := make([]*streamingproviders.Artist, 0, len(res.Data))
+	var lookupErr error
+	for i := range res.Data {
+		// Avoid looking up top tracks for artists that can't match.
+		if !streamingproviders.SameArtistName(res.Data[i].Name, a.Name) {
+			continue
+		}
+
+		// A failed top tracks lookup only rules out this artist.
+		candidate, err := p.artistFromArtist(ctx, &res.Data[i])
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 && lookupErr != nil {
+		return nil, lookupErr
+	}
+
+	return streamingproviders.BestArtistMatch(a, candidates)
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// iso8601DurationRegexp matches the time portion of an ISO 8601
+// duration (e.g., PT3M25S). Date components are not supported as no
+// song is long enough to need them.
+var iso8601DurationRegexp = regexp.MustCompile(`^PT(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:\.\d+)?S)?$`)
+
+// ParseISO8601Duration parses an ISO 8601 duration (e.g., PT3M25S) into
+// the number of seconds it represents. This is the format used by a few
+// providers' APIs (Tidal, YouTube) for track lengths.
+func ParseISO8601Duration(d string) (int, error) {
+	matches := iso8601DurationRegexp.FindStringSubmatch(d)
+	if matches == nil || d == "PT" {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", d)
+	}
+
+	var seconds int
+	for i, multiplier := range []int{60 * 60, 60, 1} {
+		if matches[i+1] == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(matches[i+1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", d, err)
+		}
+		seconds += v * multiplier
+	}
+
+	return seconds, nil
+}
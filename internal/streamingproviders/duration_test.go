@@ -0,0 +1,51 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import "testing"
+
+func TestParseISO8601Duration(t *testing.T) {
+	tests := []struct {
+		duration string
+		want     int
+		wantErr  bool
+	}{
+		{duration: "PT3M25S", want: 205},
+		{duration: "PT1H2M3S", want: 3723},
+		{duration: "PT45S", want: 45},
+		{duration: "PT4M", want: 240},
+		{duration: "PT1H", want: 3600},
+		{duration: "PT3M25.5S", want: 205},
+		{duration: "PT", wantErr: true},
+		{duration: "", wantErr: true},
+		{duration: "3M25S", wantErr: true},
+		{duration: "P1DT1S", wantErr: true},
+		{duration: "PT3M25", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.duration, func(t *testing.T) {
+			got, err := ParseISO8601Duration(tt.duration)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseISO8601Duration(%q) error = %v, wantErr %v", tt.duration, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseISO8601Duration(%q) = %d, want %d", tt.duration, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const (
+	// MatchThreshold is the minimum score, as returned by MatchScore, a
+	// song must have to be considered the same as another song.
+	MatchThreshold = 0.8
+
+	// matchDurationTolerance is the difference in duration, in seconds,
+	// under which two songs are considered to have the same duration.
+	matchDurationTolerance = 3
+
+	// matchDurationMax is the difference in duration, in seconds, at
+	// which two songs are considered to have nothing in common.
+	matchDurationMax = 15
+
+	// versionMismatchPenalty is the factor applied to the score of songs
+	// that are different versions (e.g., a remix and the original).
+	versionMismatchPenalty = 0.5
+
+	// explicitMismatchPenalty is subtracted from the score of songs that
+	// differ in explicitness (e.g., a clean version).
+	explicitMismatchPenalty = 0.1
+
+	// fuzzySearchCandidates is the number of search results considered
+	// by FuzzySearch.
+	fuzzySearchCandidates = 10
+)
+
+// MatchMethod describes how a provider found a song it was asked to
+// search for.
+type MatchMethod string
+
+const (
+	// MatchISRC denotes that the song was the only one with the ISRC of
+	// the song that was searched for.
+	MatchISRC MatchMethod = "isrc"
+
+	// MatchISRCMultiple denotes that the song was picked out of multiple
+	// songs with the ISRC of the song that was searched for (e.g., the
+	// same recording released on a single and a compilation).
+	MatchISRCMultiple MatchMethod = "isrc_multiple"
+
+	// MatchFuzzy denotes that the song was found using its metadata
+	// instead of its ISRC. MatchScore is set on songs found this way.
+	MatchFuzzy MatchMethod = "fuzzy"
+)
+
+// ConfidentMatchScore is the minimum MatchScore a fuzzy match must have
+// to not be considered low confidence (see Song.LowConfidence).
+const ConfidentMatchScore = 0.95
+
+// ISRCMatch returns the MatchMethod for a song picked out of n songs
+// returned when searching by ISRC.
+func ISRCMatch(n int) MatchMethod {
+	if n > 1 {
+		return MatchISRCMultiple
+	}
+	return MatchISRC
+}
+
+// LowConfidence returns true if the song was found using fuzzy matching
+// and isn't certain to be the song that was searched for, in which case
+// it should be presented to users as an approximate match.
+func (s *Song) LowConfidence() bool {
+	return s.MatchMethod == MatchFuzzy && s.MatchScore < ConfidentMatchScore
+}
+
+// featuringRegexp matches featured artists in a title, e.g.,
+// "(feat. Artist)" or " ft. Artist". Without brackets, only a trailing
+// "feat.", "ft." or "featuring" is matched (up to a " - " or bracket
+// that follows it, which is captured), as a bare "ft" or "feat" may be
+// part of the title itself.
+var featuringRegexp = regexp.MustCompile(
+	`(?i)[(\[]\s*(?:feat|ft|featuring)\b\.?[^)\]]*[)\]]|\s(?:feat\.|ft\.|featuring\b)\s.*?(\s-\s|[(\[]|$)`,
+)
+
+// remasterRegexp matches remaster markers in a title, e.g.,
+// "- Remastered 2011" or "(2009 Remaster)". Remasters are the same
+// recording, so they are ignored when comparing titles.
+var remasterRegexp = regexp.MustCompile(`(?i)[-(\[]?\s*(?:\d{4}\s+)?\bremaster(?:ed)?\b(?:\s+\d{4})?(?:\s+version)?\s*[)\]]?`)
+
+// versionMarkers are words in a title that denote a different recording
+// of a song than the original.
+var versionMarkers = []string{
+	"live", "remix", "mix", "acoustic", "instrumental", "karaoke", "demo",
+	"edit", "extended", "unplugged", "cover", "orchestral", "sped", "slowed",
+	"reverb", "nightcore", "version",
+}
+
+// normalizeTitle removes everything from a song title that differs
+// between providers (featured artists, remaster markers, casing and
+// punctuation) and returns the words that remain.
+func normalizeTitle(title string) []string {
+	title = featuringRegexp.ReplaceAllString(title, " ${1}")
+	title = remasterRegexp.ReplaceAllString(title, " ")
+	return tokenize(title)
+}
+
+// normalizeName lowercases a name (e.g., of an artist or album) and
+// removes punctuation.
+func normalizeName(name string) string {
+	return strings.Join(tokenize(name), " ")
+}
+
+// versions returns the version markers in the provided title words.
+func versions(words []string) []string {
+	var found []string
+	for _, w := range words {
+		if slices.Contains(versionMarkers, w) && !slices.Contains(found, w) {
+			found = append(found, w)
+		}
+	}
+	slices.Sort(found)
+	return found
+}
+
+// FuzzyQuery returns a free text query that can be used to search for
+// the provided song when it can't be found by ISRC. It contains the
+// song's normalized title and primary artist.
+func FuzzyQuery(song *Song) string {
+	query := strings.Join(normalizeTitle(song.Title), " ")
+	if len(song.Artists) > 0 {
+		query += " " + normalizeName(song.Artists[0])
+	}
+	return query
+}
+
+// similarity returns how similar two strings are, between 0 and 1,
+// based on their Levenshtein distance.
+func similarity(a, b string) float64 {
+	ar, br := []rune(a), []rune(b)
+	if len(ar) == 0 && len(br) == 0 {
+		return 1
+	}
+
+	// Only two rows of the distance matrix are needed at a time.
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return 1 - float64(prev[len(br)])/float64(max(len(ar), len(br)))
+}
+
+// artistSimilarity returns how similar the primary artist of want is to
+// the most similar artist of candidate. Providers don't agree on the
+// order (or even the split) of artists, so every artist is considered.
+func artistSimilarity(want, candidate *Song) float64 {
+	if len(want.Artists) == 0 || len(candidate.Artists) == 0 {
+		return 0
+	}
+
+	primary := normalizeName(want.Artists[0])
+	var best float64
+	for _, a := range candidate.Artists {
+		best = max(best, similarity(primary, normalizeName(a)))
+	}
+	return best
+}
+
+// durationSimilarity returns how similar two durations, in seconds, are.
+func durationSimilarity(a, b int) float64 {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+
+	switch {
+	case diff <= matchDurationTolerance:
+		return 1
+	case diff >= matchDurationMax:
+		return 0
+	default:
+		return 1 - float64(diff-matchDurationTolerance)/float64(matchDurationMax-matchDurationTolerance)
+	}
+}
+
+// MatchScore returns how likely it is, between 0 and 1, that candidate
+// is the same song as want. It is used when songs can't be matched by
+// ISRC. The score is a weighted average of the similarity of the songs'
+// titles, artists, durations and albums (when known), penalized when
+// they're different versions of a song (e.g., live or remix) or differ
+// in explicitness (when known by both providers).
+func MatchScore(want, candidate *Song) float64 {
+	wantTitle, candidateTitle := normalizeTitle(want.Title), normalizeTitle(candidate.Title)
+
+	type component struct {
+		weight, score float64
+	}
+	components := []component{
+		{0.45, similarity(strings.Join(wantTitle, " "), strings.Join(candidateTitle, " "))},
+		{0.3, artistSimilarity(want, candidate)},
+	}
+	if want.Duration > 0 && candidate.Duration > 0 {
+		components = append(components, component{0.15, durationSimilarity(want.Duration, candidate.Duration)})
+	}
+	if want.Album != "" && candidate.Album != "" {
+		// Albums are remastered (and renamed) like songs are.
+		wantAlbum, candidateAlbum := strings.Join(normalizeTitle(want.Album), " "), strings.Join(normalizeTitle(candidate.Album), " ")
+		components = append(components, component{0.1, similarity(wantAlbum, candidateAlbum)})
+	}
+
+	var score, total float64
+	for _, c := range components {
+		score += c.weight * c.score
+		total += c.weight
+	}
+	score /= total
+
+	if !slices.Equal(versions(wantTitle), versions(candidateTitle)) {
+		score *= versionMismatchPenalty
+	}
+	if !want.ExplicitUnknown && !candidate.ExplicitUnknown && want.Explicit != candidate.Explicit {
+		score -= explicitMismatchPenalty
+	}
+
+	return max(score, 0)
+}
+
+// BestMatch returns the candidate with the highest MatchScore for want,
+// with its MatchMethod and MatchScore fields set. If no candidate
+// scores at least MatchThreshold, an error wrapping ErrNotFound is
+// returned.
+func BestMatch(want *Song, candidates []*Song) (*Song, error) {
+	var best *Song
+	var bestScore float64
+	for _, c := range candidates {
+		if score := MatchScore(want, c); best == nil || score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+
+	if best == nil || bestScore < MatchThreshold {
+		return nil, fmt.Errorf("%w: no candidate scored above the match threshold (best: %.2f)", ErrNotFound, bestScore)
+	}
+
+	best.MatchMethod = MatchFuzzy
+	best.MatchScore = bestScore
+	return best, nil
+}
+
+// FuzzySearch searches the provided provider for the provided song
+// using its metadata instead of its ISRC. It should be used by Search
+// implementations when the song has no ISRC or the provider doesn't
+// know about it (e.g., because distributors assigned different ISRCs).
+// The returned song has its MatchMethod and MatchScore fields set.
+func FuzzySearch(ctx context.Context, sp TextSearchProvider, song *Song) (*Song, error) {
+	candidates, err := sp.SearchText(ctx, FuzzyQuery(song), fuzzySearchCandidates)
+	if err != nil {
+		return nil, err
+	}
+	return BestMatch(song, candidates)
+}
+
+// ISRCCandidate is a song returned by a provider when searching for an
+// ISRC, along with the information used to choose between multiple of
+// them (see BestISRCCandidate).
+type ISRCCandidate struct {
+	// Song is the song that was returned.
+	Song *Song
+
+	// ReleaseDate is the release date of the song's album, in YYYY-MM-DD
+	// format or a prefix of it (e.g., YYYY). Empty if unknown.
+	ReleaseDate string
+
+	// Compilation denotes that the song's album is a compilation (e.g.,
+	// a "Greatest Hits" album).
+	Compilation bool
+
+	// Popularity is how popular the song is on the provider, higher
+	// being more popular. Only compared between candidates of the same
+	// provider. Zero if unknown.
+	Popularity int
+}
+
+// BestISRCCandidate returns the song, out of those returned when
+// searching for the ISRC of want, that is most likely to be the release
+// want is from, with its MatchMethod field set. The same recording is
+// often released on multiple albums (e.g., a single, the original album
+// and compilations), so candidates are ranked by, in order:
+//
+//   - Having the same album title as want.
+//   - Not being on a compilation.
+//   - Having the same explicitness as want (unless unknown).
+//   - Being released first.
+//   - Being the most popular.
+//
+// Candidates that rank equally keep the order they were returned in.
+// BestISRCCandidate panics if there are no candidates.
+func BestISRCCandidate(want *Song, candidates []ISRCCandidate) *Song {
+	album := strings.Join(normalizeTitle(want.Album), " ")
+	sameAlbum := func(c *ISRCCandidate) bool {
+		return album != "" && strings.Join(normalizeTitle(c.Song.Album), " ") == album
+	}
+
+	explicitMismatch := func(c *ISRCCandidate) bool {
+		return !want.ExplicitUnknown && c.Song.Explicit != want.Explicit
+	}
+
+	// Returns -1 if a is preferred over b, 1 if b is preferred and 0 if
+	// they are equal. Booleans sort false first, so preferred values are
+	// negated.
+	rank := func(a, b ISRCCandidate) int {
+		return cmp.Or(
+			cmpBool(!sameAlbum(&a), !sameAlbum(&b)),
+			cmpBool(a.Compilation, b.Compilation),
+			cmpBool(explicitMismatch(&a), explicitMismatch(&b)),
+			cmpReleaseDate(a.ReleaseDate, b.ReleaseDate),
+			cmp.Compare(b.Popularity, a.Popularity),
+		)
+	}
+
+	best := slices.MinFunc(candidates, rank)
+	best.Song.MatchMethod = ISRCMatch(len(candidates))
+	return best.Song
+}
+
+// cmpBool compares two booleans, false being less than true.
+func cmpBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case !a:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// cmpReleaseDate compares two release dates, earlier dates being less
+// than later ones. Unknown (empty) dates are greater than every date.
+func cmpReleaseDate(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
@@ -0,0 +1,383 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Get Lucky (feat. Pharrell Williams)", "get lucky"},
+		{"Get Lucky [ft. Pharrell Williams]", "get lucky"},
+		{"Get Lucky (Featuring Pharrell Williams)", "get lucky"},
+		{"Get Lucky feat. Pharrell Williams", "get lucky"},
+		{"Get Lucky featuring Pharrell Williams", "get lucky"},
+		{"Get Lucky ft. Pharrell Williams - Radio Edit", "get lucky radio edit"},
+		{"Get Lucky feat. Pharrell Williams (Live)", "get lucky live"},
+		{"Daft Punk ft Something Else Here", "daft punk ft something else here"},
+		{"Left Behind", "left behind"},
+		{"Bohemian Rhapsody - Remastered 2011", "bohemian rhapsody"},
+		{"Bohemian Rhapsody (2011 Remaster)", "bohemian rhapsody"},
+		{"Bohemian Rhapsody - Live Aid", "bohemian rhapsody live aid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := strings.Join(normalizeTitle(tt.title), " "); got != tt.want {
+				t.Errorf("normalizeTitle(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyQuery(t *testing.T) {
+	song := &Song{Title: "Get Lucky (feat. Pharrell Williams)", Artists: []string{"Daft Punk", "Pharrell Williams"}}
+	if got, want := FuzzyQuery(song), "get lucky daft punk"; got != want {
+		t.Errorf("FuzzyQuery() = %q, want %q", got, want)
+	}
+}
+
+// getLucky returns a song used as the base of matching tests.
+func getLucky() *Song {
+	return &Song{
+		Title:    "Get Lucky (feat. Pharrell Williams)",
+		Artists:  []string{"Daft Punk", "Pharrell Williams"},
+		Album:    "Random Access Memories",
+		Duration: 369,
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		name      string
+		want      func(*Song)
+		candidate func(*Song)
+
+		// score is the expected score, or -1 if only matches is checked.
+		score   float64
+		matches bool
+	}{
+		{
+			name:      "same song",
+			candidate: func(*Song) {},
+			score:     1,
+			matches:   true,
+		},
+		{
+			name: "featured artist only in title",
+			candidate: func(s *Song) {
+				s.Title = "Get Lucky"
+				s.Artists = []string{"Daft Punk"}
+			},
+			score:   1,
+			matches: true,
+		},
+		{
+			name: "featured artist without brackets",
+			candidate: func(s *Song) {
+				s.Title = "Get Lucky ft. Pharrell Williams"
+			},
+			score:   1,
+			matches: true,
+		},
+		{
+			name: "slightly different duration",
+			candidate: func(s *Song) {
+				s.Duration = 367
+			},
+			score:   1,
+			matches: true,
+		},
+		{
+			name: "remaster",
+			want: func(s *Song) {
+				s.Title = "Bohemian Rhapsody"
+				s.Artists = []string{"Queen"}
+				s.Album = "A Night at the Opera"
+				s.Duration = 354
+			},
+			candidate: func(s *Song) {
+				s.Title = "Bohemian Rhapsody - Remastered 2011"
+				s.Artists = []string{"Queen"}
+				s.Album = "A Night at the Opera (2011 Remaster)"
+				s.Duration = 355
+			},
+			score:   1,
+			matches: true,
+		},
+		{
+			name: "live version",
+			candidate: func(s *Song) {
+				s.Title = "Get Lucky (Live)"
+			},
+			score:   -1,
+			matches: false,
+		},
+		{
+			name: "remix",
+			candidate: func(s *Song) {
+				s.Title = "Get Lucky - Daft Punk Remix"
+			},
+			score:   -1,
+			matches: false,
+		},
+		{
+			name: "bare ft in title",
+			want: func(s *Song) {
+				s.Title = "Daft Punk ft Something Else Here"
+				s.Album = ""
+				s.Duration = 0
+			},
+			candidate: func(s *Song) {
+				s.Title = "Daft Punk"
+				s.Album = ""
+				s.Duration = 0
+			},
+			score:   -1,
+			matches: false,
+		},
+		{
+			name: "different song",
+			candidate: func(s *Song) {
+				s.Title = "Instant Crush"
+				s.Duration = 337
+			},
+			score:   -1,
+			matches: false,
+		},
+		{
+			name: "explicitness mismatch",
+			want: func(s *Song) {
+				s.Explicit = true
+			},
+			candidate: func(*Song) {},
+			score:     1 - explicitMismatchPenalty,
+			matches:   true,
+		},
+		{
+			name: "unknown explicitness of candidate",
+			want: func(s *Song) {
+				s.Explicit = true
+			},
+			candidate: func(s *Song) {
+				s.ExplicitUnknown = true
+			},
+			score:   1,
+			matches: true,
+		},
+		{
+			name: "unknown explicitness of want",
+			want: func(s *Song) {
+				s.ExplicitUnknown = true
+			},
+			candidate: func(s *Song) {
+				s.Explicit = true
+			},
+			score:   1,
+			matches: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, candidate := getLucky(), getLucky()
+			if tt.want != nil {
+				tt.want(want)
+			}
+			tt.candidate(candidate)
+
+			got := MatchScore(want, candidate)
+			if tt.score >= 0 && math.Abs(got-tt.score) > 1e-9 {
+				t.Errorf("MatchScore() = %v, want %v", got, tt.score)
+			}
+			if matches := got >= MatchThreshold; matches != tt.matches {
+				t.Errorf("MatchScore() = %v, want match = %v", got, tt.matches)
+			}
+		})
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+	live := getLucky()
+	live.Title = "Get Lucky (Live)"
+	edit := getLucky()
+	edit.Title = "Get Lucky"
+	edit.Duration = 380
+	exact := getLucky()
+	other := getLucky()
+	other.Title = "Instant Crush"
+
+	t.Run("picks the highest score", func(t *testing.T) {
+		got, err := BestMatch(getLucky(), []*Song{live, edit, exact})
+		if err != nil {
+			t.Fatalf("BestMatch() error = %v", err)
+		}
+		if got != exact {
+			t.Errorf("BestMatch() = %q (%d), want %q (%d)", got.Title, got.Duration, exact.Title, exact.Duration)
+		}
+		if got.MatchMethod != MatchFuzzy || got.MatchScore != 1 {
+			t.Errorf("BestMatch() method = %q, score = %v, want %q, 1", got.MatchMethod, got.MatchScore, MatchFuzzy)
+		}
+		if got.LowConfidence() {
+			t.Error("LowConfidence() = true, want false")
+		}
+	})
+
+	t.Run("below the threshold", func(t *testing.T) {
+		if _, err := BestMatch(getLucky(), []*Song{live, other}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("BestMatch() error = %v, want %v", err, ErrNotFound)
+		}
+	})
+
+	t.Run("no candidates", func(t *testing.T) {
+		if _, err := BestMatch(getLucky(), nil); !errors.Is(err, ErrNotFound) {
+			t.Errorf("BestMatch() error = %v, want %v", err, ErrNotFound)
+		}
+	})
+}
+
+func TestBestISRCCandidate(t *testing.T) {
+	single := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Get Lucky"},
+		ReleaseDate: "2013-04-19",
+		Popularity:  50,
+	}
+	album := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Random Access Memories"},
+		ReleaseDate: "2013-05-17",
+		Popularity:  80,
+	}
+	compilation := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Now That's What I Call Music! 85"},
+		ReleaseDate: "2013-01-01",
+		Compilation: true,
+		Popularity:  90,
+	}
+	clean := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Random Access Memories"},
+		ReleaseDate: "2013-05-17",
+	}
+	explicit := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Random Access Memories", Explicit: true},
+		ReleaseDate: "2013-05-17",
+	}
+	lateClean := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Random Access Memories"},
+		ReleaseDate: "2013-06-01",
+	}
+	cleanCopy := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Random Access Memories"},
+		ReleaseDate: "2013-05-17",
+	}
+	undated := ISRCCandidate{
+		Song: &Song{Title: "Get Lucky", Album: "Get Lucky (Remixes)"},
+	}
+	popular := ISRCCandidate{
+		Song:        &Song{Title: "Get Lucky", Album: "Get Lucky (Radio Edit)"},
+		ReleaseDate: "2013-04-19",
+		Popularity:  70,
+	}
+
+	tests := []struct {
+		name       string
+		want       *Song
+		candidates []ISRCCandidate
+		expected   *Song
+		method     MatchMethod
+	}{
+		{
+			name:       "only candidate",
+			want:       &Song{},
+			candidates: []ISRCCandidate{album},
+			expected:   album.Song,
+			method:     MatchISRC,
+		},
+		{
+			name:       "same album",
+			want:       &Song{Album: "Random Access Memories"},
+			candidates: []ISRCCandidate{single, compilation, album},
+			expected:   album.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "same album ignoring remaster marker",
+			want:       &Song{Album: "Random Access Memories (Remastered)"},
+			candidates: []ISRCCandidate{single, album},
+			expected:   album.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "earliest non-compilation",
+			want:       &Song{Album: "Daft Punk Greatest Hits"},
+			candidates: []ISRCCandidate{compilation, album, single},
+			expected:   single.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "known release dates first",
+			want:       &Song{},
+			candidates: []ISRCCandidate{undated, album},
+			expected:   album.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "same explicitness",
+			want:       &Song{Album: "Random Access Memories", Explicit: true},
+			candidates: []ISRCCandidate{clean, explicit},
+			expected:   explicit.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "unknown explicitness",
+			want:       &Song{Album: "Random Access Memories", ExplicitUnknown: true},
+			candidates: []ISRCCandidate{lateClean, explicit},
+			expected:   explicit.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "most popular",
+			want:       &Song{},
+			candidates: []ISRCCandidate{single, popular},
+			expected:   popular.Song,
+			method:     MatchISRCMultiple,
+		},
+		{
+			name:       "ties keep their order",
+			want:       &Song{Album: "Random Access Memories"},
+			candidates: []ISRCCandidate{cleanCopy, clean},
+			expected:   cleanCopy.Song,
+			method:     MatchISRCMultiple,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BestISRCCandidate(tt.want, tt.candidates)
+			if got != tt.expected {
+				t.Errorf("BestISRCCandidate() = %q, want %q", got.Album, tt.expected.Album)
+			}
+			if got.MatchMethod != tt.method {
+				t.Errorf("BestISRCCandidate() method = %q, want %q", got.MatchMethod, tt.method)
+			}
+		})
+	}
+}
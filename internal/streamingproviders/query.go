@@ -0,0 +1,70 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenize lowercases the provided string and splits it into words,
+// ignoring punctuation.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// QueryScore returns a confidence score, between 0 and 1, of how well
+// the provided song matches a free text search query (e.g., "never gonna
+// give you up rick astley").
+//
+// The score is the average of how much of the query is contained in the
+// song's title and artists, and how much of the song's title is
+// contained in the query. This penalizes songs that only partially match
+// the query (e.g., a remix when the original was searched for).
+func QueryScore(query string, song *Song) float64 {
+	queryTokens := tokenize(query)
+	titleTokens := tokenize(song.Title)
+	if len(queryTokens) == 0 || len(titleTokens) == 0 {
+		return 0
+	}
+
+	songTokens := make(map[string]struct{})
+	for _, t := range tokenize(song.Title + " " + strings.Join(song.Artists, " ")) {
+		songTokens[t] = struct{}{}
+	}
+
+	queryLookup := make(map[string]struct{}, len(queryTokens))
+	var queryInSong int
+	for _, t := range queryTokens {
+		queryLookup[t] = struct{}{}
+		if _, ok := songTokens[t]; ok {
+			queryInSong++
+		}
+	}
+
+	var titleInQuery int
+	for _, t := range titleTokens {
+		if _, ok := queryLookup[t]; ok {
+			titleInQuery++
+		}
+	}
+
+	return (float64(queryInSong)/float64(len(queryTokens)) + float64(titleInQuery)/float64(len(titleTokens))) / 2
+}
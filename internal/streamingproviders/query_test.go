@@ -0,0 +1,70 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+package streamingproviders
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQueryScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		song  *Song
+		want  float64
+	}{
+		{
+			name:  "title and artist",
+			query: "never gonna give you up rick astley",
+			song:  &Song{Title: "Never Gonna Give You Up", Artists: []string{"Rick Astley"}},
+			want:  1,
+		},
+		{
+			name:  "punctuation and casing",
+			query: "NEVER GONNA GIVE YOU UP!",
+			song:  &Song{Title: "Never Gonna Give You Up", Artists: []string{"Rick Astley"}},
+			want:  1,
+		},
+		{
+			name:  "remix",
+			query: "never gonna give you up rick astley",
+			song:  &Song{Title: "Never Gonna Give You Up (Remix)", Artists: []string{"Rick Astley"}},
+			want:  (1 + 5.0/6) / 2,
+		},
+		{
+			name:  "title only partially in query",
+			query: "never gonna",
+			song:  &Song{Title: "Never Gonna Give You Up", Artists: []string{"Rick Astley"}},
+			want:  (1 + 2.0/5) / 2,
+		},
+		{
+			name:  "empty query",
+			query: "",
+			song:  &Song{Title: "Never Gonna Give You Up", Artists: []string{"Rick Astley"}},
+			want:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := QueryScore(tt.query, tt.song); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("QueryScore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -20,10 +20,14 @@ package spotify
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/charmbracelet/log"
@@ -37,25 +41,75 @@ import (
 // interface.
 var _ streamingproviders.Provider = &Provider{}
 
+// _ ensures that Provider implements the
+// streamingproviders.AlbumProvider interface.
+var _ streamingproviders.AlbumProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.ArtistProvider interface.
+var _ streamingproviders.ArtistProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.PlaylistProvider interface.
+var _ streamingproviders.PlaylistProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.TextSearchProvider interface.
+var _ streamingproviders.TextSearchProvider = &Provider{}
+
+// topTracksCountry is the country used when looking up an artist's top
+// tracks if a market isn't configured.
+const topTracksCountry = "US"
+
+// marketRegexp matches a valid market (ISO 3166-1 alpha-2 country code,
+// uppercased).
+var marketRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
 // Provider implements a streamingproviders.Provider for Spotify.
 type Provider struct {
 	client *gospotify.Client
+
+	// market is the market (e.g., "US") songs should be playable in, or
+	// empty if songs aren't checked for availability.
+	market string
 }
 
 // New returns a new Spotify client using the following environment
 // variables:
 // - MIKU_SPOTIFY_CLIENT_ID
 // - MIKU_SPOTIFY_CLIENT_SECRET
+// - MIKU_SPOTIFY_MARKET (optional)
 func New(ctx context.Context, _ *log.Logger) (streamingproviders.Provider, error) {
 	clientID := os.Getenv("MIKU_SPOTIFY_CLIENT_ID")
 	clientSecret := os.Getenv("MIKU_SPOTIFY_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("%w: MIKU_SPOTIFY_CLIENT_ID and MIKU_SPOTIFY_CLIENT_SECRET must be set",
+			streamingproviders.ErrNotConfigured)
+	}
+
+	market := strings.ToUpper(os.Getenv("MIKU_SPOTIFY_MARKET"))
+	if market != "" && !marketRegexp.MatchString(market) {
+		return nil, fmt.Errorf("invalid MIKU_SPOTIFY_MARKET %q, expected a two letter country code", market)
+	}
 
 	config := &clientcredentials.Config{
 		ClientID:     clientID,
 		ClientSecret: clientSecret,
 		TokenURL:     gospotifyauth.TokenURL,
 	}
-	return &Provider{gospotify.New(config.Client(ctx))}, nil
+
+	return &Provider{client: gospotify.New(config.Client(ctx)), market: market}, nil
+}
+
+// marketOptions returns the request options that make Spotify return
+// tracks playable in the configured market. When a track isn't playable
+// there, Spotify returns a playable copy of it instead, if there is one
+// (known as track relinking).
+func (p *Provider) marketOptions() []gospotify.RequestOption {
+	if p.market == "" {
+		return nil
+	}
+	return []gospotify.RequestOption{gospotify.Market(p.market)}
 }
 
 // Info returns information about this provider.
@@ -93,9 +147,31 @@ func (p *Provider) songFromTrack(t *gospotify.FullTrack) *streamingproviders.Son
 		// Convert milliseconds to seconds.
 		Duration:    int(t.Duration) / 1000,
 		AlbumArtURL: albumArtURL,
+		Explicit:    t.Explicit,
+
+		AvailableMarkets: p.availableMarkets(t),
+		RegionRestricted: t.IsPlayable != nil && !*t.IsPlayable,
 	}
 }
 
+// availableMarkets returns the markets the provided track is available
+// in. Spotify only returns them when a market isn't requested, in which
+// case it reports whether the track is playable in that market instead.
+func (p *Provider) availableMarkets(t *gospotify.FullTrack) []string {
+	if t.IsPlayable != nil {
+		if *t.IsPlayable {
+			return []string{strings.ToLower(p.market)}
+		}
+		return nil
+	}
+
+	markets := make([]string, 0, len(t.AvailableMarkets))
+	for _, m := range t.AvailableMarkets {
+		markets = append(markets, strings.ToLower(m))
+	}
+	return markets
+}
+
 // LookupSongByURL returns a song from the provided URL. The URL must
 // match the following format:
 // - https://open.spotify.com/track/1qRbITa6QZoD6kQpBLMgao
@@ -105,7 +181,9 @@ func (p *Provider) LookupSongByURL(ctx context.Context, u *url.URL) (*streamingp
 		return nil, fmt.Errorf("invalid path: %s", trackPath)
 	}
 
-	track, err := p.client.GetTrack(ctx, gospotify.ID(ID))
+	// If the track has been relinked, the playable copy of it is
+	// returned (with LinkedFrom pointing to ID).
+	track, err := p.client.GetTrack(ctx, gospotify.ID(ID), p.marketOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find track with ID %s: %w", ID, err)
 	}
@@ -116,15 +194,265 @@ func (p *Provider) LookupSongByURL(ctx context.Context, u *url.URL) (*streamingp
 // Search returns a song from this provider using a Song provided from
 // another provider.
 func (p *Provider) Search(ctx context.Context, song *streamingproviders.Song) (*streamingproviders.Song, error) {
-	res, err := p.client.Search(ctx, "isrc:"+song.ISRC, gospotify.SearchTypeTrack)
+	if song.ISRC == "" {
+		return streamingproviders.FuzzySearch(ctx, p, song)
+	}
+
+	res, err := p.client.Search(ctx, "isrc:"+song.ISRC, gospotify.SearchTypeTrack, p.marketOptions()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search for song: %w", err)
 	}
 
 	if res.Tracks == nil || res.Tracks.Tracks == nil || len(res.Tracks.Tracks) == 0 {
-		return nil, fmt.Errorf("no tracks returned")
+		// The ISRC may differ between distributors.
+		return streamingproviders.FuzzySearch(ctx, p, song)
+	}
+
+	candidates := make([]streamingproviders.ISRCCandidate, 0, len(res.Tracks.Tracks))
+	for i := range res.Tracks.Tracks {
+		t := &res.Tracks.Tracks[i]
+		candidates = append(candidates, streamingproviders.ISRCCandidate{
+			Song:        p.songFromTrack(t),
+			ReleaseDate: t.Album.ReleaseDate,
+			Compilation: t.Album.AlbumType == "compilation",
+			Popularity:  int(t.Popularity),
+		})
 	}
 
-	track := res.Tracks.Tracks[0]
-	return p.songFromTrack(&track), nil
+	// Only consider releases that can't be played in the configured
+	// market if there's nothing else.
+	playable := slices.DeleteFunc(slices.Clone(candidates), func(c streamingproviders.ISRCCandidate) bool {
+		return c.Song.RegionRestricted
+	})
+	if len(playable) > 0 {
+		candidates = playable
+	}
+
+	return streamingproviders.BestISRCCandidate(song, candidates), nil
+}
+
+// albumFromFullAlbum converts a gospotify.FullAlbum to a
+// streamingproviders.Album.
+func (p *Provider) albumFromFullAlbum(a *gospotify.FullAlbum) *streamingproviders.Album {
+	strArtists := make([]string, 0, len(a.Artists))
+	for _, artist := range a.Artists {
+		strArtists = append(strArtists, artist.Name)
+	}
+
+	var artworkURL string
+	if len(a.Images) > 0 {
+		artworkURL = a.Images[0].URL
+	}
+
+	return &streamingproviders.Album{
+		Provider:    p.Info(),
+		ProviderURL: a.ExternalURLs["spotify"],
+		UPC:         a.ExternalIDs["upc"],
+		Title:       a.Name,
+		Artists:     strArtists,
+		ArtworkURL:  artworkURL,
+		ReleaseDate: a.ReleaseDate,
+		TrackCount:  int(a.TotalTracks),
+	}
+}
+
+// LookupAlbumByURL returns an album from the provided URL. The URL must
+// match the following format:
+// - https://open.spotify.com/album/4aawyAB9vmqN3uQ7FjRGTy
+func (p *Provider) LookupAlbumByURL(ctx context.Context, u *url.URL) (*streamingproviders.Album, error) {
+	albumPath, ID := path.Split(u.Path)
+	if albumPath != "/album/" {
+		return nil, fmt.Errorf("invalid path: %s", albumPath)
+	}
+
+	album, err := p.client.GetAlbum(ctx, gospotify.ID(ID), p.marketOptions()...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find album with ID %s: %w", ID, err)
+	}
+
+	return p.albumFromFullAlbum(album), nil
+}
+
+// SearchAlbum returns an album from this provider using an Album
+// provided from another provider.
+func (p *Provider) SearchAlbum(ctx context.Context, album *streamingproviders.Album) (*streamingproviders.Album, error) {
+	if album.UPC == "" {
+		return nil, fmt.Errorf("album has no UPC")
+	}
+
+	for _, upc := range streamingproviders.UPCVariants(album.UPC) {
+		res, err := p.client.Search(ctx, "upc:"+upc, gospotify.SearchTypeAlbum, p.marketOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search for album: %w", err)
+		}
+		if res.Albums == nil || len(res.Albums.Albums) == 0 {
+			continue
+		}
+
+		// Search only returns simplified albums, which lack the UPC.
+		full, err := p.client.GetAlbum(ctx, res.Albums.Albums[0].ID, p.marketOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get album: %w", err)
+		}
+
+		return p.albumFromFullAlbum(full), nil
+	}
+
+	return nil, fmt.Errorf("%w: no albums returned", streamingproviders.ErrNotFound)
+}
+
+// artistFromFullArtist converts a gospotify.FullArtist to a
+// streamingproviders.Artist, looking up the artist's top tracks.
+func (p *Provider) artistFromFullArtist(ctx context.Context, a *gospotify.FullArtist) (*streamingproviders.Artist, error) {
+	country := topTracksCountry
+	if p.market != "" {
+		country = p.market
+	}
+
+	tracks, err := p.client.GetArtistsTopTracks(ctx, a.ID, country)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top tracks: %w", err)
+	}
+
+	isrcs := make([]string, 0, len(tracks))
+	for i := range tracks {
+		if isrc := tracks[i].ExternalIDs["isrc"]; isrc != "" {
+			isrcs = append(isrcs, isrc)
+		}
+	}
+
+	var imageURL string
+	if len(a.Images) > 0 {
+		imageURL = a.Images[0].URL
+	}
+
+	return &streamingproviders.Artist{
+		Provider:      p.Info(),
+		ProviderURL:   a.ExternalURLs["spotify"],
+		Name:          a.Name,
+		ImageURL:      imageURL,
+		TopTrackISRCs: isrcs,
+	}, nil
+}
+
+// LookupArtistByURL returns an artist from the provided URL. The URL
+// must match the following format:
+// - https://open.spotify.com/artist/0gxyHStUsqpMadRV0Di1Qt
+func (p *Provider) LookupArtistByURL(ctx context.Context, u *url.URL) (*streamingproviders.Artist, error) {
+	artistPath, ID := path.Split(u.Path)
+	if artistPath != "/artist/" {
+		return nil, fmt.Errorf("invalid path: %s", artistPath)
+	}
+
+	artist, err := p.client.GetArtist(ctx, gospotify.ID(ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find artist with ID %s: %w", ID, err)
+	}
+
+	return p.artistFromFullArtist(ctx, artist)
+}
+
+// SearchArtist returns an artist from this provider using an Artist
+// provided from another provider.
+func (p *Provider) SearchArtist(ctx context.Context, artist *streamingproviders.Artist) (*streamingproviders.Artist, error) {
+	opts := append(p.marketOptions(), gospotify.Limit(5))
+	res, err := p.client.Search(ctx, artist.Name, gospotify.SearchTypeArtist, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for artist: %w", err)
+	}
+
+	if res.Artists == nil || len(res.Artists.Artists) == 0 {
+		return nil, fmt.Errorf("%w: no artists returned", streamingproviders.ErrNotFound)
+	}
+
+	candidates := make([]*streamingproviders.Artist, 0, len(res.Artists.Artists))
+	var lookupErr error
+	for i := range res.Artists.Artists {
+		// Avoid looking up top tracks for artists that can't match.
+		if !streamingproviders.SameArtistName(res.Artists.Artists[i].Name, artist.Name) {
+			continue
+		}
+
+		// An artist whose top tracks can't be looked up is skipped, as
+		// one of the other candidates may still match.
+		candidate, err := p.artistFromFullArtist(ctx, &res.Artists.Artists[i])
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 && lookupErr != nil {
+		return nil, lookupErr
+	}
+
+	return streamingproviders.BestArtistMatch(artist, candidates)
+}
+
+// LookupPlaylistByURL returns a playlist from the provided URL. The URL
+// must match the following format:
+// - https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M
+func (p *Provider) LookupPlaylistByURL(ctx context.Context, u *url.URL, maxSongs int) (*streamingproviders.Playlist, error) {
+	playlistPath, ID := path.Split(u.Path)
+	if playlistPath != "/playlist/" {
+		return nil, fmt.Errorf("invalid path: %s", playlistPath)
+	}
+
+	playlist, err := p.client.GetPlaylist(ctx, gospotify.ID(ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find playlist with ID %s: %w", ID, err)
+	}
+
+	var imageURL string
+	if len(playlist.Images) > 0 {
+		imageURL = playlist.Images[0].URL
+	}
+
+	pl := &streamingproviders.Playlist{
+		Provider:    p.Info(),
+		ProviderURL: playlist.ExternalURLs["spotify"],
+		Name:        playlist.Name,
+		Owner:       playlist.Owner.DisplayName,
+		ImageURL:    imageURL,
+	}
+
+	items, err := p.client.GetPlaylistItems(ctx, playlist.ID)
+	for err == nil {
+		for i := range items.Items {
+			// Episodes and tracks unavailable in the market are nil.
+			if t := items.Items[i].Track.Track; t != nil && len(pl.Songs) < maxSongs {
+				pl.Songs = append(pl.Songs, p.songFromTrack(t))
+			}
+		}
+		if len(pl.Songs) >= maxSongs {
+			break
+		}
+
+		err = p.client.NextPage(ctx, items)
+	}
+	if err != nil && !errors.Is(err, gospotify.ErrNoMorePages) {
+		return nil, fmt.Errorf("failed to get playlist items: %w", err)
+	}
+
+	return pl, nil
+}
+
+// SearchText returns songs from this provider matching the provided
+// free text query.
+func (p *Provider) SearchText(ctx context.Context, query string, limit int) ([]*streamingproviders.Song, error) {
+	opts := append(p.marketOptions(), gospotify.Limit(limit))
+	res, err := p.client.Search(ctx, query, gospotify.SearchTypeTrack, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for songs: %w", err)
+	}
+
+	if res.Tracks == nil || len(res.Tracks.Tracks) == 0 {
+		return nil, fmt.Errorf("%w: no tracks returned", streamingproviders.ErrNotFound)
+	}
+
+	songs := make([]*streamingproviders.Song, 0, len(res.Tracks.Tracks))
+	for i := range res.Tracks.Tracks {
+		songs = append(songs, p.songFromTrack(&res.Tracks.Tracks[i]))
+	}
+	return songs, nil
 }
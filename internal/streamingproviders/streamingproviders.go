@@ -22,11 +22,24 @@ package streamingproviders
 
 import (
 	"context"
+	"errors"
 	"net/url"
+	"slices"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// ErrNotFound is returned (wrapped) by providers when the provider
+// successfully searched for something, but it doesn't exist on the
+// provider. This is in contrast to other errors, which may be transient
+// (e.g., rate limits).
+var ErrNotFound = errors.New("not found")
+
+// ErrNotConfigured is returned (wrapped) by the New function of a
+// provider when it hasn't been configured (e.g., its credentials aren't
+// set). Such providers are skipped, while other errors are fatal.
+var ErrNotConfigured = errors.New("provider not configured")
+
 // Song is a music track.
 type Song struct {
 	// Provider is the name of the provider that returned this song.
@@ -56,6 +69,115 @@ type Song struct {
 
 	// Duration is the duration of the song in seconds.
 	Duration int
+
+	// Explicit denotes that the song contains explicit content.
+	Explicit bool
+
+	// ExplicitUnknown denotes that the provider doesn't know whether the
+	// song contains explicit content, in which case Explicit is always
+	// false and shouldn't be compared.
+	ExplicitUnknown bool
+
+	// AvailableMarkets contains the markets (lowercase ISO 3166-1 alpha-2
+	// country codes, e.g., "us") the song is known to be available in.
+	// Providers may only check some markets, so this isn't exhaustive.
+	// Empty if unknown.
+	AvailableMarkets []string
+
+	// RegionRestricted denotes that the song exists on the provider, but
+	// can't be played in the region the provider is configured for.
+	RegionRestricted bool
+
+	// MatchMethod is how the provider found this song when it was asked
+	// to search for it. Empty if the song wasn't searched for (e.g., it
+	// was looked up by URL).
+	MatchMethod MatchMethod
+
+	// MatchScore is how confident the provider is, between 0 and 1, that
+	// this song is the song it was asked to search for. Only set when the
+	// song was found using fuzzy matching (see BestMatch), as ISRC
+	// matches are exact.
+	MatchScore float64
+}
+
+// Album is a collection of songs released together.
+type Album struct {
+	// Provider is the name of the provider that returned this album.
+	Provider Info
+
+	// ProviderURL is the URL of the album on the provider's website. This
+	// should be publicly accessible.
+	ProviderURL string
+
+	// UPC is the universal product code for the album. This is used to
+	// uniquely identify an album.
+	UPC string
+
+	// Title is the title of the album.
+	Title string
+
+	// Artists is the list of artists on the album. The first artist is
+	// considered the primary artist.
+	Artists []string
+
+	// ArtworkURL is the URL of the artwork for the album. This must be
+	// publicly accessible.
+	ArtworkURL string
+
+	// ReleaseDate is the date the album was released, as returned by the
+	// provider. This is usually in the format YYYY-MM-DD, but may be less
+	// precise (e.g., YYYY).
+	ReleaseDate string
+
+	// TrackCount is the number of tracks on the album.
+	TrackCount int
+}
+
+// Artist is a musician or group of musicians.
+type Artist struct {
+	// Provider is the name of the provider that returned this artist.
+	Provider Info
+
+	// ProviderURL is the URL of the artist on the provider's website. This
+	// should be publicly accessible.
+	ProviderURL string
+
+	// Name is the name of the artist.
+	Name string
+
+	// ImageURL is the URL of an image of the artist. This must be
+	// publicly accessible.
+	ImageURL string
+
+	// TopTrackISRCs are the ISRCs of the artist's most popular songs on
+	// the provider. Artists have no universal identifier, so these are
+	// used to tell apart artists that share a name.
+	TopTrackISRCs []string
+}
+
+// Playlist is a user or editorially curated list of songs.
+type Playlist struct {
+	// Provider is the name of the provider that returned this playlist.
+	Provider Info
+
+	// ProviderURL is the URL of the playlist on the provider's website.
+	// This should be publicly accessible.
+	ProviderURL string
+
+	// Name is the name of the playlist.
+	Name string
+
+	// Owner is the name of the user (or curator) that created the
+	// playlist.
+	Owner string
+
+	// ImageURL is the URL of the playlist's cover image. This must be
+	// publicly accessible.
+	ImageURL string
+
+	// Songs are the songs in the playlist, in order. Items that aren't
+	// songs (e.g., podcast episodes) are not included.
+	Songs []*Song
 }
 
 // NewProvider is a function that returns a new Provider. If a provider
@@ -83,6 +205,25 @@ type Info struct {
 	// If not set, then the provider will be provided all URLs and the
 	// provider should abort if it cannot handle the URL.
 	URLHostname string
+
+	// AdditionalURLHostnames are other hostnames the provider's links may
+	// be served from (e.g., a web player on a separate subdomain). These
+	// are only considered if URLHostname is set.
+	AdditionalURLHostnames []string
+}
+
+// MatchesHostname returns true if a URL with the provided hostname
+// should be passed to the provider described by this Info.
+func (i *Info) MatchesHostname(hostname string) bool {
+	if i.URLHostname == "" {
+		return true
+	}
+
+	if hostname == i.URLHostname {
+		return true
+	}
+
+	return slices.Contains(i.AdditionalURLHostnames, hostname)
 }
 
 // Provider is a streaming provider interface capable of looking up
@@ -99,3 +240,47 @@ type Provider interface {
 	// another provider.
 	Search(ctx context.Context, song *Song) (*Song, error)
 }
+
+// AlbumProvider is implemented by a Provider that is capable of looking
+// up albums. Providers that do not implement this interface will not be
+// used for album links.
+type AlbumProvider interface {
+	// LookupAlbumByURL returns an album from the provided URL.
+	LookupAlbumByURL(ctx context.Context, url *url.URL) (*Album, error)
+
+	// SearchAlbum returns an album from this provider using an Album
+	// provided from another provider.
+	SearchAlbum(ctx context.Context, album *Album) (*Album, error)
+}
+
+// ArtistProvider is implemented by a Provider that is capable of looking
+// up artists. Providers that do not implement this interface will not be
+// used for artist links.
+type ArtistProvider interface {
+	// LookupArtistByURL returns an artist from the provided URL.
+	LookupArtistByURL(ctx context.Context, url *url.URL) (*Artist, error)
+
+	// SearchArtist returns an artist from this provider using an Artist
+	// provided from another provider. Implementations should use
+	// [BestArtistMatch] to pick between artists with the same name.
+	SearchArtist(ctx context.Context, artist *Artist) (*Artist, error)
+}
+
+// TextSearchProvider is implemented by a Provider that is capable of
+// searching its catalog using free text.
+type TextSearchProvider interface {
+	// SearchText returns up to limit songs matching the provided query,
+	// in the order the provider ranks them.
+	SearchText(ctx context.Context, query string, limit int) ([]*Song, error)
+}
+
+// PlaylistProvider is implemented by a Provider that is capable of
+// looking up playlists. Playlists only exist on the provider they were
+// created on, so there is no search counterpart. Instead, each song is
+// searched for individually.
+type PlaylistProvider interface {
+	// LookupPlaylistByURL returns a playlist, and the songs in it, from
+	// the provided URL. Implementations may return only the first
+	// maxSongs songs of the playlist.
+	LookupPlaylistByURL(ctx context.Context, url *url.URL, maxSongs int) (*Playlist, error)
+}
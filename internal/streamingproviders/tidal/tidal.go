@@ -0,0 +1,609 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+// Package tidal implements a streamingproviders.Provider for the Tidal
+// streaming service.
+package tidal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/streamingproviders"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	// tokenURL is the URL used to obtain client-credentials tokens.
+	tokenURL = "https://auth.tidal.com/v1/oauth2/token"
+
+	// apiBaseURL is the base URL of the Tidal Open API.
+	apiBaseURL = "https://openapi.tidal.com/v2"
+
+	// defaultCountryCode is the country code used for all requests if
+	// MIKU_TIDAL_COUNTRY_CODE isn't set.
+	defaultCountryCode = "US"
+
+	// topTracksLimit is the number of tracks used to tell apart artists
+	// that share a name.
+	topTracksLimit = 3
+
+	// artistSearchLimit is the number of search results considered when
+	// searching for an artist.
+	artistSearchLimit = 5
+)
+
+// countryCodeRegexp matches a valid country code (ISO 3166-1 alpha-2,
+// uppercased).
+var countryCodeRegexp = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// _ ensures that Provider implements the streamingproviders.Provider
+// interface.
+var _ streamingproviders.Provider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.AlbumProvider interface.
+var _ streamingproviders.AlbumProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.ArtistProvider interface.
+var _ streamingproviders.ArtistProvider = &Provider{}
+
+// _ ensures that Provider implements the
+// streamingproviders.TextSearchProvider interface.
+var _ streamingproviders.TextSearchProvider = &Provider{}
+
+// Provider implements a streamingproviders.Provider for Tidal.
+type Provider struct {
+	client *http.Client
+
+	// countryCode is the country code used for all requests. Tidal only
+	// returns content available in that country.
+	countryCode string
+}
+
+// New returns a new Tidal client using the following environment
+// variables:
+// - MIKU_TIDAL_CLIENT_ID
+// - MIKU_TIDAL_CLIENT_SECRET
+// - MIKU_TIDAL_COUNTRY_CODE (optional, defaults to US)
+func New(ctx context.Context, logger *log.Logger) (streamingproviders.Provider, error) {
+	clientID := os.Getenv("MIKU_TIDAL_CLIENT_ID")
+	clientSecret := os.Getenv("MIKU_TIDAL_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("%w: MIKU_TIDAL_CLIENT_ID and MIKU_TIDAL_CLIENT_SECRET must be set",
+			streamingproviders.ErrNotConfigured)
+	}
+
+	country := defaultCountryCode
+	if cc := os.Getenv("MIKU_TIDAL_COUNTRY_CODE"); cc != "" {
+		country = strings.ToUpper(cc)
+		if !countryCodeRegexp.MatchString(country) {
+			return nil, fmt.Errorf("invalid MIKU_TIDAL_COUNTRY_CODE %q, expected a two letter country code", cc)
+		}
+	}
+
+	config := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+	}
+
+	// Fail early if the credentials are rejected. Other errors (e.g., the
+	// network being unavailable) may be temporary, so they don't prevent
+	// the provider from starting.
+	if _, err := config.Token(ctx); err != nil {
+		var rerr *oauth2.RetrieveError
+		if errors.As(err, &rerr) && rerr.Response != nil &&
+			(rerr.Response.StatusCode == http.StatusUnauthorized || rerr.Response.StatusCode == http.StatusForbidden) {
+			return nil, fmt.Errorf("failed to authenticate with Tidal: %w", err)
+		}
+		logger.With("err", err).Warn("failed to authenticate with Tidal, continuing anyway")
+	}
+
+	return &Provider{client: config.Client(ctx), countryCode: country}, nil
+}
+
+// Info returns information about this provider.
+func (p *Provider) Info() streamingproviders.Info {
+	return streamingproviders.Info{
+		Identifier: "tidal",
+		Name:       "Tidal",
+		Emoji: discordgo.ComponentEmoji{
+			Name: "🌊",
+		},
+		URLHostname:            "tidal.com",
+		AdditionalURLHostnames: []string{"www.tidal.com", "listen.tidal.com"},
+	}
+}
+
+// resourceIdentifier is a JSON:API resource identifier.
+type resourceIdentifier struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// relationship is a JSON:API relationship containing many resources.
+type relationship struct {
+	Data []resourceIdentifier `json:"data"`
+}
+
+// link is a link to an external resource (e.g., an image or a sharing
+// URL).
+type link struct {
+	Href string `json:"href"`
+	Meta struct {
+		Type   string `json:"type"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	} `json:"meta"`
+}
+
+// resource is a JSON:API resource. Only the attributes used by this
+// package are decoded.
+type resource struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	Attributes struct {
+		// Tracks and albums.
+		Title         string `json:"title"`
+		ISRC          string `json:"isrc"`
+		Duration      string `json:"duration"`
+		Explicit      bool   `json:"explicit"`
+		ExternalLinks []link `json:"externalLinks"`
+
+		// Albums and artists.
+		ImageLinks []link `json:"imageLinks"`
+
+		// Albums.
+		BarcodeID     string `json:"barcodeId"`
+		NumberOfItems int    `json:"numberOfItems"`
+		ReleaseDate   string `json:"releaseDate"`
+
+		// Artists.
+		Name string `json:"name"`
+	} `json:"attributes"`
+	Relationships struct {
+		Artists relationship `json:"artists"`
+		Albums  relationship `json:"albums"`
+	} `json:"relationships"`
+}
+
+// document is a JSON:API document containing one or more resources along
+// with their included resources.
+type document struct {
+	Data     json.RawMessage `json:"data"`
+	Included []resource      `json:"included"`
+}
+
+// get performs a GET request against the Tidal API and decodes the
+// response into a document. The include parameter controls which
+// relationships are returned alongside the requested resources, if any.
+func (p *Provider) get(ctx context.Context, endpoint, include string, query url.Values) (*document, error) {
+	query.Set("countryCode", p.countryCode)
+	if include != "" {
+		query.Set("include", include)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBaseURL+endpoint+"?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var doc document
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &doc, nil
+}
+
+// songFromTrack converts a Tidal track resource, and the resources
+// included alongside it, into a streamingproviders.Song.
+func (p *Provider) songFromTrack(t *resource, included []resource) (*streamingproviders.Song, error) {
+	duration, err := streamingproviders.ParseISO8601Duration(t.Attributes.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse track duration: %w", err)
+	}
+
+	lookup := lookupTable(included)
+	artists := artistNames(t, lookup)
+
+	var album, albumArtURL string
+	if len(t.Relationships.Albums.Data) > 0 {
+		if a, ok := lookup[t.Relationships.Albums.Data[0]]; ok {
+			album = a.Attributes.Title
+			albumArtURL = smallestImage(a.Attributes.ImageLinks)
+		}
+	}
+
+	return &streamingproviders.Song{
+		Provider:    p.Info(),
+		ProviderURL: sharingURL(t, "https://tidal.com/browse/track/"+t.ID),
+		ISRC:        t.Attributes.ISRC,
+		Title:       t.Attributes.Title,
+		Artists:     artists,
+		Album:       album,
+		AlbumArtURL: albumArtURL,
+		Duration:    duration,
+		Explicit:    t.Attributes.Explicit,
+	}, nil
+}
+
+// lookupTable returns a map of included resources keyed by their
+// resource identifier.
+func lookupTable(included []resource) map[resourceIdentifier]*resource {
+	lookup := make(map[resourceIdentifier]*resource, len(included))
+	for i := range included {
+		lookup[resourceIdentifier{included[i].ID, included[i].Type}] = &included[i]
+	}
+	return lookup
+}
+
+// artistNames returns the names of the artists related to the provided
+// resource.
+func artistNames(r *resource, lookup map[resourceIdentifier]*resource) []string {
+	artists := make([]string, 0, len(r.Relationships.Artists.Data))
+	for _, ri := range r.Relationships.Artists.Data {
+		if artist, ok := lookup[ri]; ok {
+			artists = append(artists, artist.Attributes.Name)
+		}
+	}
+	return artists
+}
+
+// sharingURL returns the public sharing URL of the provided resource,
+// falling back to the provided URL if one isn't present.
+func sharingURL(r *resource, fallback string) string {
+	for _, l := range r.Attributes.ExternalLinks {
+		if l.Meta.Type == "TIDAL_SHARING" {
+			return l.Href
+		}
+	}
+	return fallback
+}
+
+// smallestImage returns the URL of the smallest image that is at least
+// 100px wide, falling back to the first image if none are.
+func smallestImage(links []link) string {
+	var best *link
+	for i := range links {
+		l := &links[i]
+		if l.Meta.Width < 100 {
+			continue
+		}
+		if best == nil || l.Meta.Width < best.Meta.Width {
+			best = l
+		}
+	}
+	if best == nil && len(links) > 0 {
+		best = &links[0]
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Href
+}
+
+// idFromURL returns the ID of the resource of the provided kind (e.g.,
+// track) from a Tidal URL.
+func idFromURL(u *url.URL, kind string) (string, error) {
+	kindPath, id := path.Split(strings.TrimSuffix(u.Path, "/"))
+	if kindPath != "/"+kind+"/" && kindPath != "/browse/"+kind+"/" {
+		return "", fmt.Errorf("invalid path: %s", kindPath)
+	}
+	return id, nil
+}
+
+// LookupSongByURL returns a song from the provided URL. The URL must
+// match one of the following formats:
+// - https://tidal.com/track/123456789
+// - https://tidal.com/browse/track/123456789
+// - https://listen.tidal.com/track/123456789
+func (p *Provider) LookupSongByURL(ctx context.Context, u *url.URL) (*streamingproviders.Song, error) {
+	id, err := idFromURL(u, "track")
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := p.get(ctx, "/tracks/"+id, "artists,albums", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find track with ID %s: %w", id, err)
+	}
+
+	var track resource
+	if err := json.Unmarshal(doc.Data, &track); err != nil {
+		return nil, fmt.Errorf("failed to decode track: %w", err)
+	}
+
+	return p.songFromTrack(&track, doc.Included)
+}
+
+// Search returns a song from this provider using a Song provided from
+// another provider.
+func (p *Provider) Search(ctx context.Context, song *streamingproviders.Song) (*streamingproviders.Song, error) {
+	if song.ISRC == "" {
+		return streamingproviders.FuzzySearch(ctx, p, song)
+	}
+
+	doc, err := p.get(ctx, "/tracks", "artists,albums", url.Values{"filter[isrc]": []string{song.ISRC}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for song: %w", err)
+	}
+
+	var tracks []resource
+	if err := json.Unmarshal(doc.Data, &tracks); err != nil {
+		return nil, fmt.Errorf("failed to decode tracks: %w", err)
+	}
+	if len(tracks) == 0 {
+		// The ISRC may differ between distributors.
+		return streamingproviders.FuzzySearch(ctx, p, song)
+	}
+
+	lookup := lookupTable(doc.Included)
+	candidates := make([]streamingproviders.ISRCCandidate, 0, len(tracks))
+	for i := range tracks {
+		t := &tracks[i]
+		alt, err := p.songFromTrack(t, doc.Included)
+		if err != nil {
+			return nil, err
+		}
+
+		var releaseDate string
+		if len(t.Relationships.Albums.Data) > 0 {
+			if a, ok := lookup[t.Relationships.Albums.Data[0]]; ok {
+				releaseDate = a.Attributes.ReleaseDate
+			}
+		}
+		candidates = append(candidates, streamingproviders.ISRCCandidate{Song: alt, ReleaseDate: releaseDate})
+	}
+
+	return streamingproviders.BestISRCCandidate(song, candidates), nil
+}
+
+// SearchText returns songs from this provider matching the provided
+// free text query.
+func (p *Provider) SearchText(ctx context.Context, query string, limit int) ([]*streamingproviders.Song, error) {
+	// Search results only contain the IDs of the tracks, so the tracks
+	// themselves (with their artists and albums) are fetched afterwards.
+	results, err := p.get(ctx, "/searchResults/"+url.PathEscape(query)+"/relationships/tracks", "", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for songs: %w", err)
+	}
+
+	var ids []resourceIdentifier
+	if err := json.Unmarshal(results.Data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no tracks returned", streamingproviders.ErrNotFound)
+	}
+
+	trackIDs := make([]string, 0, min(len(ids), limit))
+	for _, ri := range ids[:min(len(ids), limit)] {
+		trackIDs = append(trackIDs, ri.ID)
+	}
+
+	doc, err := p.get(ctx, "/tracks", "artists,albums", url.Values{"filter[id]": trackIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks: %w", err)
+	}
+
+	var tracks []resource
+	if err := json.Unmarshal(doc.Data, &tracks); err != nil {
+		return nil, fmt.Errorf("failed to decode tracks: %w", err)
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("%w: no tracks returned", streamingproviders.ErrNotFound)
+	}
+
+	songs := make([]*streamingproviders.Song, 0, len(tracks))
+	for i := range tracks {
+		song, err := p.songFromTrack(&tracks[i], doc.Included)
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, song)
+	}
+	return songs, nil
+}
+
+// albumFromAlbum converts a Tidal album resource, and the resources
+// included alongside it, into a streamingproviders.Album.
+func (p *Provider) albumFromAlbum(a *resource, included []resource) *streamingproviders.Album {
+	return &streamingproviders.Album{
+		Provider:    p.Info(),
+		ProviderURL: sharingURL(a, "https://tidal.com/browse/album/"+a.ID),
+		UPC:         a.Attributes.BarcodeID,
+		Title:       a.Attributes.Title,
+		Artists:     artistNames(a, lookupTable(included)),
+		ArtworkURL:  smallestImage(a.Attributes.ImageLinks),
+		ReleaseDate: a.Attributes.ReleaseDate,
+		TrackCount:  a.Attributes.NumberOfItems,
+	}
+}
+
+// LookupAlbumByURL returns an album from the provided URL. The URL must
+// match one of the following formats:
+// - https://tidal.com/album/123456789
+// - https://tidal.com/browse/album/123456789
+// - https://listen.tidal.com/album/123456789
+func (p *Provider) LookupAlbumByURL(ctx context.Context, u *url.URL) (*streamingproviders.Album, error) {
+	id, err := idFromURL(u, "album")
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := p.get(ctx, "/albums/"+id, "artists", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find album with ID %s: %w", id, err)
+	}
+
+	var a resource
+	if err := json.Unmarshal(doc.Data, &a); err != nil {
+		return nil, fmt.Errorf("failed to decode album: %w", err)
+	}
+
+	return p.albumFromAlbum(&a, doc.Included), nil
+}
+
+// SearchAlbum returns an album from this provider using an Album
+// provided from another provider.
+func (p *Provider) SearchAlbum(ctx context.Context, album *streamingproviders.Album) (*streamingproviders.Album, error) {
+	if album.UPC == "" {
+		return nil, fmt.Errorf("album has no UPC")
+	}
+
+	doc, err := p.get(ctx, "/albums", "artists", url.Values{"filter[barcodeId]": streamingproviders.UPCVariants(album.UPC)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for album: %w", err)
+	}
+
+	var albums []resource
+	if err := json.Unmarshal(doc.Data, &albums); err != nil {
+		return nil, fmt.Errorf("failed to decode albums: %w", err)
+	}
+	if len(albums) == 0 {
+		return nil, fmt.Errorf("%w: no albums returned", streamingproviders.ErrNotFound)
+	}
+
+	return p.albumFromAlbum(&albums[0], doc.Included), nil
+}
+
+// artistFromArtist converts a Tidal artist resource into a
+// streamingproviders.Artist, looking up the artist's tracks. Tidal has
+// no top tracks endpoint, so the first of the artist's tracks are used
+// instead, with releases of the same recording collapsed into one.
+func (p *Provider) artistFromArtist(ctx context.Context, a *resource) (*streamingproviders.Artist, error) {
+	doc, err := p.get(ctx, "/artists/"+a.ID+"/relationships/tracks", "tracks",
+		url.Values{"collapseBy": []string{"FINGERPRINT"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tracks: %w", err)
+	}
+
+	var ids []resourceIdentifier
+	if err := json.Unmarshal(doc.Data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode tracks: %w", err)
+	}
+
+	lookup := lookupTable(doc.Included)
+	isrcs := make([]string, 0, topTracksLimit)
+	for _, ri := range ids {
+		if len(isrcs) == topTracksLimit {
+			break
+		}
+		if t, ok := lookup[ri]; ok && t.Attributes.ISRC != "" {
+			isrcs = append(isrcs, t.Attributes.ISRC)
+		}
+	}
+
+	return &streamingproviders.Artist{
+		Provider:      p.Info(),
+		ProviderURL:   sharingURL(a, "https://tidal.com/browse/artist/"+a.ID),
+		Name:          a.Attributes.Name,
+		ImageURL:      smallestImage(a.Attributes.ImageLinks),
+		TopTrackISRCs: isrcs,
+	}, nil
+}
+
+// LookupArtistByURL returns an artist from the provided URL. The URL
+// must match one of the following formats:
+// - https://tidal.com/artist/123456789
+// - https://tidal.com/browse/artist/123456789
+// - https://listen.tidal.com/artist/123456789
+func (p *Provider) LookupArtistByURL(ctx context.Context, u *url.URL) (*streamingproviders.Artist, error) {
+	id, err := idFromURL(u, "artist")
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := p.get(ctx, "/artists/"+id, "", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find artist with ID %s: %w", id, err)
+	}
+
+	var a resource
+	if err := json.Unmarshal(doc.Data, &a); err != nil {
+		return nil, fmt.Errorf("failed to decode artist: %w", err)
+	}
+
+	return p.artistFromArtist(ctx, &a)
+}
+
+// SearchArtist returns an artist from this provider using an Artist
+// provided from another provider.
+func (p *Provider) SearchArtist(ctx context.Context, artist *streamingproviders.Artist) (*streamingproviders.Artist, error) {
+	doc, err := p.get(ctx, "/searchResults/"+url.PathEscape(artist.Name)+"/relationships/artists", "artists", url.Values{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for artist: %w", err)
+	}
+
+	var ids []resourceIdentifier
+	if err := json.Unmarshal(doc.Data, &ids); err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no artists returned", streamingproviders.ErrNotFound)
+	}
+
+	lookup := lookupTable(doc.Included)
+	candidates := make([]*streamingproviders.Artist, 0, artistSearchLimit)
+	var lookupErr error
+	for _, ri := range ids[:min(len(ids), artistSearchLimit)] {
+		// Avoid looking up tracks for artists that can't match.
+		found, ok := lookup[ri]
+		if !ok || !streamingproviders.SameArtistName(found.Attributes.Name, artist.Name) {
+			continue
+		}
+
+		// The artist is skipped if its tracks can't be looked up, as
+		// another candidate may still match.
+		candidate, err := p.artistFromArtist(ctx, found)
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	if len(candidates) == 0 && lookupErr != nil {
+		return nil, lookupErr
+	}
+
+	return streamingproviders.BestArtistMatch(artist, candidates)
+}
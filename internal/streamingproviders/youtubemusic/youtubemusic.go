@@ -0,0 +1,354 @@
+// Copyright (C) 2026 miku contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: GPL-3.0
+
+// Package youtubemusic implements a streamingproviders.Provider for
+// YouTube Music using the YouTube Data API.
+package youtubemusic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"github.com/jaredallard/miku/internal/streamingproviders"
+)
+
+const (
+	// apiBaseURL is the base URL of the YouTube Data API.
+	apiBaseURL = "https://www.googleapis.com/youtube/v3"
+
+	// musicHostname is the hostname of YouTube Music.
+	musicHostname = "music.youtube.com"
+
+	// musicCategoryID is the YouTube video category for music.
+	musicCategoryID = "10"
+
+	// durationTolerance is the maximum difference, in seconds, between
+	// the duration of a song and a search result for the result to be
+	// considered the same song.
+	durationTolerance = 5
+
+	// topicChannelSuffix is the suffix YouTube adds to the name of
+	// auto-generated artist channels, which contain the official
+	// releases of songs.
+	topicChannelSuffix = " - Topic"
+)
+
+// _ ensures that Provider implements the streamingproviders.Provider
+// interface.
+var _ streamingproviders.Provider = &Provider{}
+
+// Provider implements a streamingproviders.Provider for YouTube Music.
+type Provider struct {
+	client *http.Client
+	apiKey string
+}
+
+// New returns a new YouTube Music client using the following
+// environment variables:
+// - MIKU_YOUTUBE_API_KEY
+func New(_ context.Context, _ *log.Logger) (streamingproviders.Provider, error) {
+	apiKey := os.Getenv("MIKU_YOUTUBE_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: MIKU_YOUTUBE_API_KEY must be set", streamingproviders.ErrNotConfigured)
+	}
+
+	return &Provider{http.DefaultClient, apiKey}, nil
+}
+
+// Info returns information about this provider.
+func (p *Provider) Info() streamingproviders.Info {
+	return streamingproviders.Info{
+		Identifier: "youtubemusic",
+		Name:       "YouTube Music",
+		Emoji: discordgo.ComponentEmoji{
+			Name: "▶️",
+		},
+		URLHostname: musicHostname,
+		// Regular YouTube links (e.g., from youtu.be short links) are
+		// supported as long as they point to a music video.
+		AdditionalURLHostnames: []string{"www.youtube.com", "youtube.com", "m.youtube.com"},
+	}
+}
+
+// thumbnail is an image returned by the YouTube Data API.
+type thumbnail struct {
+	URL string `json:"url"`
+}
+
+// video is a video returned by the YouTube Data API. Only the fields
+// used by this package are decoded.
+type video struct {
+	// ID is the ID of the video. Returned as an object by search
+	// requests, so it's decoded separately.
+	ID json.RawMessage `json:"id"`
+
+	Snippet struct {
+		Title        string               `json:"title"`
+		Description  string               `json:"description"`
+		ChannelTitle string               `json:"channelTitle"`
+		CategoryID   string               `json:"categoryId"`
+		Thumbnails   map[string]thumbnail `json:"thumbnails"`
+	} `json:"snippet"`
+
+	ContentDetails struct {
+		Duration string `json:"duration"`
+	} `json:"contentDetails"`
+}
+
+// videoID returns the ID of the video regardless of it being returned
+// from a list or search request.
+func (v *video) videoID() string {
+	var id string
+	if err := json.Unmarshal(v.ID, &id); err == nil {
+		return id
+	}
+
+	var searchID struct {
+		VideoID string `json:"videoId"`
+	}
+	if err := json.Unmarshal(v.ID, &searchID); err == nil {
+		return searchID.VideoID
+	}
+
+	return ""
+}
+
+// get performs a GET request against the YouTube Data API and returns
+// the videos in the response.
+func (p *Provider) get(ctx context.Context, endpoint string, query url.Values) ([]video, error) {
+	query.Set("key", p.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiBaseURL+endpoint+"?"+query.Encode(), http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var res struct {
+		Items []video `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return res.Items, nil
+}
+
+// getVideos returns the videos with the provided IDs.
+func (p *Provider) getVideos(ctx context.Context, ids ...string) ([]video, error) {
+	return p.get(ctx, "/videos", url.Values{
+		"part": []string{"snippet,contentDetails"},
+		"id":   []string{strings.Join(ids, ",")},
+	})
+}
+
+// songFromVideo converts a YouTube video into a streamingproviders.Song.
+//
+// Songs uploaded by YouTube on behalf of labels ("Topic" channels)
+// contain structured information in their description, which is
+// preferred. Otherwise, the "Artist - Title" convention is assumed.
+func (p *Provider) songFromVideo(v *video) (*streamingproviders.Song, error) {
+	duration, err := streamingproviders.ParseISO8601Duration(v.ContentDetails.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse video duration: %w", err)
+	}
+
+	song := &streamingproviders.Song{
+		Provider:    p.Info(),
+		ProviderURL: "https://music.youtube.com/watch?v=" + v.videoID(),
+		Title:       v.Snippet.Title,
+		Duration:    duration,
+
+		// YouTube doesn't mark videos as explicit.
+		ExplicitUnknown: true,
+	}
+
+	for _, size := range []string{"medium", "high", "default"} {
+		if t, ok := v.Snippet.Thumbnails[size]; ok {
+			song.AlbumArtURL = t.URL
+			break
+		}
+	}
+
+	if title, artists, album, ok := parseAutoGeneratedDescription(v.Snippet.Description); ok {
+		song.Title = title
+		song.Artists = artists
+		song.Album = album
+		return song, nil
+	}
+
+	if artist, ok := strings.CutSuffix(v.Snippet.ChannelTitle, topicChannelSuffix); ok {
+		song.Artists = []string{artist}
+	} else if artist, title, ok := strings.Cut(v.Snippet.Title, " - "); ok {
+		song.Title = strings.TrimSpace(title)
+		song.Artists = []string{strings.TrimSpace(artist)}
+	} else {
+		song.Artists = []string{v.Snippet.ChannelTitle}
+	}
+
+	return song, nil
+}
+
+// parseAutoGeneratedDescription parses the description of a video
+// uploaded to a "Topic" channel. These are formatted as:
+//
+//	Provided to YouTube by <Distributor>
+//
+//	<Title> · <Artist> · <Artist>
+//
+//	<Album>
+//	...
+func parseAutoGeneratedDescription(desc string) (title string, artists []string, album string, ok bool) {
+	if !strings.HasPrefix(desc, "Provided to YouTube by") {
+		return "", nil, "", false
+	}
+
+	var lines []string
+	for line := range strings.SplitSeq(desc, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) < 3 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(lines[1], " · ")
+	if len(parts) < 2 {
+		return "", nil, "", false
+	}
+
+	return parts[0], parts[1:], lines[2], true
+}
+
+// LookupSongByURL returns a song from the provided URL. The URL must
+// match the following format:
+// - https://music.youtube.com/watch?v=dQw4w9WgXcQ
+// - https://www.youtube.com/watch?v=dQw4w9WgXcQ (music videos only)
+func (p *Provider) LookupSongByURL(ctx context.Context, u *url.URL) (*streamingproviders.Song, error) {
+	if u.Path != "/watch" {
+		return nil, fmt.Errorf("invalid path: %s", u.Path)
+	}
+
+	id := u.Query().Get("v")
+	if id == "" {
+		return nil, fmt.Errorf("missing 'v' query parameter")
+	}
+
+	videos, err := p.getVideos(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find video with ID %s: %w", id, err)
+	}
+	if len(videos) == 0 {
+		return nil, fmt.Errorf("%w: no videos returned", streamingproviders.ErrNotFound)
+	}
+
+	// Everything on YouTube Music is a song, but that's not the case for
+	// YouTube itself.
+	if u.Hostname() != musicHostname && videos[0].Snippet.CategoryID != musicCategoryID {
+		return nil, fmt.Errorf("%w: video %s is not a music video", streamingproviders.ErrNotFound, id)
+	}
+
+	return p.songFromVideo(&videos[0])
+}
+
+// Search returns a song from this provider using a Song provided from
+// another provider. YouTube doesn't expose ISRCs, so the song's title,
+// primary artist and duration are used instead, and only videos that
+// score at least streamingproviders.MatchThreshold are returned.
+func (p *Provider) Search(ctx context.Context, song *streamingproviders.Song) (*streamingproviders.Song, error) {
+	if song.Title == "" || len(song.Artists) == 0 {
+		return nil, fmt.Errorf("song has no title or artists")
+	}
+
+	results, err := p.get(ctx, "/search", url.Values{
+		"part":            []string{"snippet"},
+		"type":            []string{"video"},
+		"videoCategoryId": []string{musicCategoryID},
+		"maxResults":      []string{"10"},
+		"q":               []string{streamingproviders.FuzzyQuery(song)},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for song: %w", err)
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("%w: no videos returned", streamingproviders.ErrNotFound)
+	}
+
+	// Search results don't contain durations, so we need to fetch the
+	// videos themselves.
+	ids := make([]string, 0, len(results))
+	for i := range results {
+		ids = append(ids, results[i].videoID())
+	}
+	videos, err := p.getVideos(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get videos: %w", err)
+	}
+
+	var candidates, official []*streamingproviders.Song
+	for i := range videos {
+		v := &videos[i]
+
+		alt, err := p.songFromVideo(v)
+		if err != nil {
+			continue
+		}
+		if song.Duration != 0 && abs(alt.Duration-song.Duration) > durationTolerance {
+			continue
+		}
+
+		candidates = append(candidates, alt)
+		if strings.HasSuffix(v.Snippet.ChannelTitle, topicChannelSuffix) {
+			official = append(official, alt)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no videos matched the song's duration", streamingproviders.ErrNotFound)
+	}
+
+	// Prefer official uploads over everything else, as long as one of
+	// them matches the song.
+	if alt, err := streamingproviders.BestMatch(song, official); err == nil {
+		return alt, nil
+	}
+	return streamingproviders.BestMatch(song, candidates)
+}
+
+// abs returns the absolute value of i.
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}